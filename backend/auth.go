@@ -0,0 +1,578 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/jackc/pgx/v5"
+)
+
+// Passkey limits
+const (
+	maxDisplayNameLength = 50
+	ceremonyTimeout      = 5 * time.Minute
+	sessionLifetime      = 90 * 24 * time.Hour
+)
+
+// WebAuthn is the relying party configuration used for all passkey ceremonies
+var WebAuthn *webauthn.WebAuthn
+
+// User represents an account that signed in with a passkey
+type User struct {
+	ID          string    `json:"id"`
+	DisplayName string    `json:"display_name"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Passkey is the public view of a registered credential
+type Passkey struct {
+	ID         string     `json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+// passkeyUser adapts a User and its stored credentials to the webauthn.User interface
+type passkeyUser struct {
+	User
+	webauthnID  []byte
+	credentials []webauthn.Credential
+}
+
+func (u *passkeyUser) WebAuthnID() []byte                         { return u.webauthnID }
+func (u *passkeyUser) WebAuthnName() string                       { return u.DisplayName }
+func (u *passkeyUser) WebAuthnDisplayName() string                { return u.DisplayName }
+func (u *passkeyUser) WebAuthnCredentials() []webauthn.Credential { return u.credentials }
+
+// InitWebAuthn configures the relying party from the environment
+// WEBAUTHN_RP_ID must be the frontend's domain (e.g. "jorlist.app"), since that's where the browser runs the ceremony
+func InitWebAuthn() {
+	rpID := os.Getenv("WEBAUTHN_RP_ID")
+	if rpID == "" {
+		rpID = "localhost"
+	}
+
+	// Allowed origins default to the CORS origin, or the Vite dev server
+	origins := []string{}
+	for _, origin := range strings.Split(os.Getenv("WEBAUTHN_RP_ORIGINS"), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	if len(origins) == 0 {
+		if corsOrigin := os.Getenv("CORS_ORIGIN"); corsOrigin != "" && corsOrigin != "*" {
+			origins = append(origins, corsOrigin)
+		} else {
+			origins = append(origins, "http://localhost:5173")
+		}
+	}
+
+	var err error
+	WebAuthn, err = webauthn.New(&webauthn.Config{
+		RPID:          rpID,
+		RPDisplayName: "JORLIST",
+		RPOrigins:     origins,
+		AuthenticatorSelection: protocol.AuthenticatorSelection{
+			ResidentKey:      protocol.ResidentKeyRequirementRequired,
+			UserVerification: protocol.VerificationPreferred,
+		},
+	})
+	if err != nil {
+		log.Fatalf("Invalid WebAuthn configuration: %v\n", err)
+	}
+}
+
+// BeginPasskeyRegistration handles POST /api/auth/passkeys/register/begin
+// Signed-in users add another passkey to their account, everyone else gets a new account
+func BeginPasskeyRegistration(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		DisplayName string `json:"display_name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	user, err := currentUser(r)
+	if err != nil && !errors.Is(err, errNotSignedIn) {
+		http.Error(w, "Invalid session", http.StatusUnauthorized)
+		return
+	}
+
+	var pkUser *passkeyUser
+	if user != nil {
+		pkUser, err = loadPasskeyUser(context.Background(), user.ID)
+		if err != nil {
+			http.Error(w, "Failed to load account", http.StatusInternalServerError)
+			return
+		}
+	} else {
+		input.DisplayName = strings.TrimSpace(input.DisplayName)
+		if input.DisplayName == "" {
+			http.Error(w, "Display name is required", http.StatusBadRequest)
+			return
+		}
+		if len(input.DisplayName) > maxDisplayNameLength {
+			http.Error(w, fmt.Sprintf("Display name must be %d characters or less", maxDisplayNameLength), http.StatusBadRequest)
+			return
+		}
+
+		// The account row is only written once the passkey is verified
+		webauthnID := make([]byte, 64)
+		rand.Read(webauthnID)
+		pkUser = &passkeyUser{User: User{DisplayName: input.DisplayName}, webauthnID: webauthnID}
+	}
+
+	options, session, err := WebAuthn.BeginRegistration(pkUser,
+		webauthn.WithExclusions(webauthn.Credentials(pkUser.credentials).CredentialDescriptors()))
+	if err != nil {
+		http.Error(w, "Failed to start passkey registration", http.StatusInternalServerError)
+		return
+	}
+
+	ceremonyID, err := saveCeremony(context.Background(), "registration", pkUser.ID, struct {
+		Session     *webauthn.SessionData `json:"session"`
+		DisplayName string                `json:"display_name"`
+	}{session, pkUser.DisplayName})
+	if err != nil {
+		http.Error(w, "Failed to start passkey registration", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"ceremony_id": ceremonyID,
+		"options":     options,
+	})
+}
+
+// FinishPasskeyRegistration handles POST /api/auth/passkeys/register/finish?ceremony_id=...
+// The body is the PublicKeyCredential returned by navigator.credentials.create()
+func FinishPasskeyRegistration(w http.ResponseWriter, r *http.Request) {
+	var pending struct {
+		Session     webauthn.SessionData `json:"session"`
+		DisplayName string               `json:"display_name"`
+	}
+	userID, err := takeCeremony(context.Background(), r.URL.Query().Get("ceremony_id"), "registration", &pending)
+	if err != nil {
+		http.Error(w, "Registration expired or not found", http.StatusBadRequest)
+		return
+	}
+
+	pkUser := &passkeyUser{User: User{DisplayName: pending.DisplayName}, webauthnID: pending.Session.UserID}
+	if userID != nil {
+		if pkUser, err = loadPasskeyUser(context.Background(), *userID); err != nil {
+			http.Error(w, "Account not found", http.StatusNotFound)
+			return
+		}
+	}
+
+	credential, err := WebAuthn.FinishRegistration(pkUser, pending.Session, r)
+	if err != nil {
+		http.Error(w, "Passkey verification failed", http.StatusBadRequest)
+		return
+	}
+	credentialJSON, err := json.Marshal(credential)
+	if err != nil {
+		http.Error(w, "Failed to save passkey", http.StatusInternalServerError)
+		return
+	}
+
+	tx, err := DB.Begin(context.Background())
+	if err != nil {
+		http.Error(w, "Failed to save passkey", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	// New accounts are created together with their first passkey
+	if userID == nil {
+		err = tx.QueryRow(context.Background(),
+			`INSERT INTO users (webauthn_id, display_name) VALUES ($1, $2)
+			 RETURNING id, display_name, created_at`,
+			pkUser.webauthnID, pkUser.DisplayName,
+		).Scan(&pkUser.ID, &pkUser.DisplayName, &pkUser.CreatedAt)
+		if err != nil {
+			http.Error(w, "Failed to create account", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	_, err = tx.Exec(context.Background(),
+		"INSERT INTO passkey_credentials (id, user_id, credential) VALUES ($1, $2, $3)",
+		credential.ID, pkUser.ID, credentialJSON)
+	if err != nil {
+		http.Error(w, "Passkey is already registered", http.StatusConflict)
+		return
+	}
+
+	token, err := createSession(context.Background(), tx, pkUser.ID)
+	if err != nil {
+		http.Error(w, "Failed to create session", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(context.Background()); err != nil {
+		http.Error(w, "Failed to save passkey", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]any{
+		"token": token,
+		"user":  pkUser.User,
+	})
+}
+
+// BeginPasskeyLogin handles POST /api/auth/passkeys/login/begin
+// Uses discoverable credentials, so the user doesn't need to type anything
+func BeginPasskeyLogin(w http.ResponseWriter, r *http.Request) {
+	options, session, err := WebAuthn.BeginDiscoverableLogin()
+	if err != nil {
+		http.Error(w, "Failed to start passkey sign-in", http.StatusInternalServerError)
+		return
+	}
+
+	ceremonyID, err := saveCeremony(context.Background(), "login", "", session)
+	if err != nil {
+		http.Error(w, "Failed to start passkey sign-in", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"ceremony_id": ceremonyID,
+		"options":     options,
+	})
+}
+
+// FinishPasskeyLogin handles POST /api/auth/passkeys/login/finish?ceremony_id=...
+// The body is the PublicKeyCredential returned by navigator.credentials.get()
+func FinishPasskeyLogin(w http.ResponseWriter, r *http.Request) {
+	var session webauthn.SessionData
+	if _, err := takeCeremony(context.Background(), r.URL.Query().Get("ceremony_id"), "login", &session); err != nil {
+		http.Error(w, "Sign-in expired or not found", http.StatusBadRequest)
+		return
+	}
+
+	// Look up the account by the user handle stored on the passkey
+	findUser := func(rawID, userHandle []byte) (webauthn.User, error) {
+		var userID string
+		err := DB.QueryRow(context.Background(),
+			"SELECT id FROM users WHERE webauthn_id = $1", userHandle).Scan(&userID)
+		if err != nil {
+			return nil, err
+		}
+		return loadPasskeyUser(context.Background(), userID)
+	}
+
+	found, credential, err := WebAuthn.FinishPasskeyLogin(findUser, session, r)
+	if err != nil {
+		http.Error(w, "Passkey verification failed", http.StatusUnauthorized)
+		return
+	}
+	pkUser := found.(*passkeyUser)
+
+	// Persist the new signature counter so cloned authenticators can be detected.
+	// A counter that didn't increase sets CloneWarning, which is stored too, so the passkey stays blocked
+	credentialJSON, err := json.Marshal(credential)
+	if err != nil {
+		http.Error(w, "Failed to update passkey", http.StatusInternalServerError)
+		return
+	}
+	if _, err := DB.Exec(context.Background(),
+		"UPDATE passkey_credentials SET credential = $1, last_used_at = NOW() WHERE id = $2",
+		credentialJSON, credential.ID); err != nil {
+		http.Error(w, "Failed to update passkey", http.StatusInternalServerError)
+		return
+	}
+	if credential.Authenticator.CloneWarning {
+		log.Printf("Passkey of user %s may be cloned (sign count %d), sign-in rejected",
+			pkUser.ID, credential.Authenticator.SignCount)
+		http.Error(w, "Passkey may have been cloned", http.StatusUnauthorized)
+		return
+	}
+
+	token, err := createSession(context.Background(), DB, pkUser.ID)
+	if err != nil {
+		http.Error(w, "Failed to create session", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"token": token,
+		"user":  pkUser.User,
+	})
+}
+
+// Logout handles POST /api/auth/logout - ends the current session
+func Logout(w http.ResponseWriter, r *http.Request) {
+	token := bearerToken(r)
+	if token == "" {
+		http.Error(w, "Not signed in", http.StatusUnauthorized)
+		return
+	}
+
+	DB.Exec(context.Background(),
+		"DELETE FROM user_sessions WHERE token_hash = $1", hashToken(token))
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetMe handles GET /api/auth/me - returns the signed-in account with its passkeys and claimed lists
+func GetMe(w http.ResponseWriter, r *http.Request) {
+	user, err := currentUser(r)
+	if err != nil {
+		http.Error(w, "Not signed in", http.StatusUnauthorized)
+		return
+	}
+
+	passkeys := []Passkey{}
+	rows, err := DB.Query(context.Background(),
+		`SELECT id, created_at, last_used_at FROM passkey_credentials
+		 WHERE user_id = $1 ORDER BY created_at ASC`, user.ID)
+	if err != nil {
+		http.Error(w, "Failed to fetch passkeys", http.StatusInternalServerError)
+		return
+	}
+	for rows.Next() {
+		var pk Passkey
+		var id []byte
+		if err := rows.Scan(&id, &pk.CreatedAt, &pk.LastUsedAt); err != nil {
+			rows.Close()
+			http.Error(w, "Failed to scan passkey", http.StatusInternalServerError)
+			return
+		}
+		pk.ID = base64.RawURLEncoding.EncodeToString(id)
+		passkeys = append(passkeys, pk)
+	}
+	rows.Close()
+
+	lists := []List{}
+	rows, err = DB.Query(context.Background(),
+		`SELECT id, name, emoji, hex_color, is_private, created_at FROM lists
+		 WHERE owner_id = $1 ORDER BY created_at ASC`, user.ID)
+	if err != nil {
+		http.Error(w, "Failed to fetch lists", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var list List
+		if err := rows.Scan(&list.ID, &list.Name, &list.Emoji, &list.HexColor, &list.IsPrivate, &list.CreatedAt); err != nil {
+			http.Error(w, "Failed to scan list", http.StatusInternalServerError)
+			return
+		}
+		lists = append(lists, list)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"user":     user,
+		"passkeys": passkeys,
+		"lists":    lists,
+	})
+}
+
+// DeletePasskey handles DELETE /api/auth/passkeys/{id} - removes one of the signed-in user's passkeys
+func DeletePasskey(w http.ResponseWriter, r *http.Request) {
+	user, err := currentUser(r)
+	if err != nil {
+		http.Error(w, "Not signed in", http.StatusUnauthorized)
+		return
+	}
+
+	credentialID, err := base64.RawURLEncoding.DecodeString(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid passkey ID", http.StatusBadRequest)
+		return
+	}
+
+	// Never remove the last passkey - the account would be locked out
+	result, err := DB.Exec(context.Background(),
+		`DELETE FROM passkey_credentials
+		 WHERE id = $1 AND user_id = $2
+		   AND (SELECT COUNT(*) FROM passkey_credentials WHERE user_id = $2) > 1`,
+		credentialID, user.ID)
+	if err != nil {
+		http.Error(w, "Failed to delete passkey", http.StatusInternalServerError)
+		return
+	}
+	if result.RowsAffected() == 0 {
+		http.Error(w, "Passkey not found or it is the last one", http.StatusConflict)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ClaimList handles POST /api/lists/{id}/claim - makes the signed-in user the owner of a list
+// Claimed lists can still be shared by link, but only the owner can delete them
+func ClaimList(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		http.Error(w, "List ID is required", http.StatusBadRequest)
+		return
+	}
+
+	user, err := currentUser(r)
+	if err != nil {
+		http.Error(w, "Not signed in", http.StatusUnauthorized)
+		return
+	}
+
+	var list List
+	var ownerID *string
+	err = DB.QueryRow(context.Background(),
+		`UPDATE lists SET owner_id = COALESCE(owner_id, $2) WHERE id = $1
+		 RETURNING id, name, emoji, hex_color, is_private, created_at, owner_id`,
+		id, user.ID).Scan(&list.ID, &list.Name, &list.Emoji, &list.HexColor, &list.IsPrivate, &list.CreatedAt, &ownerID)
+	if err != nil {
+		http.Error(w, "List not found", http.StatusNotFound)
+		return
+	}
+	if ownerID == nil || *ownerID != user.ID {
+		http.Error(w, "List is already claimed", http.StatusConflict)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// errNotSignedIn is returned by currentUser when the request has no bearer token
+var errNotSignedIn = errors.New("not signed in")
+
+// currentUser resolves the "Authorization: Bearer <token>" header to a user
+func currentUser(r *http.Request) (*User, error) {
+	token := bearerToken(r)
+	if token == "" {
+		return nil, errNotSignedIn
+	}
+
+	var user User
+	err := DB.QueryRow(context.Background(),
+		`SELECT u.id, u.display_name, u.created_at
+		 FROM user_sessions s JOIN users u ON u.id = s.user_id
+		 WHERE s.token_hash = $1 AND s.expires_at > NOW()`,
+		hashToken(token)).Scan(&user.ID, &user.DisplayName, &user.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// bearerToken extracts the token from the Authorization header
+func bearerToken(r *http.Request) string {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// hashToken returns the SHA-256 of a bearer token - only hashes are stored in the database
+func hashToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
+
+// randomToken returns a URL-safe random string with n bytes of entropy
+func randomToken(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// createSession issues a new bearer token for a user
+func createSession(ctx context.Context, db querier, userID string) (string, error) {
+	token := randomToken(32)
+	_, err := db.Exec(ctx,
+		"INSERT INTO user_sessions (token_hash, user_id, expires_at) VALUES ($1, $2, $3)",
+		hashToken(token), userID, time.Now().Add(sessionLifetime))
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// loadPasskeyUser fetches a user together with all of their credentials
+func loadPasskeyUser(ctx context.Context, userID string) (*passkeyUser, error) {
+	u := &passkeyUser{}
+	err := DB.QueryRow(ctx,
+		"SELECT id, webauthn_id, display_name, created_at FROM users WHERE id = $1",
+		userID).Scan(&u.ID, &u.webauthnID, &u.DisplayName, &u.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := DB.Query(ctx,
+		"SELECT credential FROM passkey_credentials WHERE user_id = $1", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var credential webauthn.Credential
+		if err := rows.Scan(&credential); err != nil {
+			return nil, err
+		}
+		u.credentials = append(u.credentials, credential)
+	}
+	return u, rows.Err()
+}
+
+// saveCeremony stores challenge data between the begin and finish steps
+// It lives in Postgres (not memory) so any replica can finish the ceremony
+func saveCeremony(ctx context.Context, kind, userID string, data any) (string, error) {
+	// Opportunistically clean up abandoned ceremonies
+	DB.Exec(ctx, "DELETE FROM webauthn_ceremonies WHERE expires_at < NOW()")
+
+	var owner *string
+	if userID != "" {
+		owner = &userID
+	}
+
+	id := randomToken(32)
+	_, err := DB.Exec(ctx,
+		`INSERT INTO webauthn_ceremonies (id, kind, user_id, session, expires_at)
+		 VALUES ($1, $2, $3, $4, $5)`,
+		id, kind, owner, data, time.Now().Add(ceremonyTimeout))
+	if err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// takeCeremony loads and deletes a pending ceremony, so each challenge can only be used once
+func takeCeremony(ctx context.Context, id, kind string, dest any) (*string, error) {
+	if id == "" {
+		return nil, pgx.ErrNoRows
+	}
+
+	var userID *string
+	var data []byte
+	err := DB.QueryRow(ctx,
+		`DELETE FROM webauthn_ceremonies
+		 WHERE id = $1 AND kind = $2 AND expires_at > NOW()
+		 RETURNING user_id, session`,
+		id, kind).Scan(&userID, &data)
+	if err != nil {
+		return nil, err
+	}
+	return userID, json.Unmarshal(data, dest)
+}
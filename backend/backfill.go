@@ -0,0 +1,270 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// backfillNote explains what a backfill can't do, in its response
+const backfillNote = "Every item on the lists, checked or not, counts as one addition at its creation time; " +
+	"names that are on a list several times get an interval from that. Deleted items left no trace, " +
+	"so a name that is on a list only once is suggested after it has been added one more time."
+
+// BackfillResult summarizes a history backfill
+// Items counts the records created or extended, Recommendable those of them that can be suggested
+type BackfillResult struct {
+	Lists         int    `json:"lists"`
+	Items         int    `json:"items"`
+	Additions     int    `json:"additions"`
+	Recommendable int    `json:"recommendable"`
+	Note          string `json:"note"`
+}
+
+// backfillHistory rebuilds item_history and item_additions from items that were added before history was tracked
+// The only source is the items themselves (there is no archive or activity log): every item, checked or not,
+// is one addition at its created_at. Lists from before adding an item unchecked the existing one hold
+// a checked item for each time it was bought, which gives the intervals. An empty listID backfills all lists
+func backfillHistory(ctx context.Context, listID string) (BackfillResult, error) {
+	result := BackfillResult{Note: backfillNote}
+
+	rows, err := DB.Query(ctx,
+		`SELECT DISTINCT list_id FROM items
+		 WHERE $1 = '' OR list_id = $1`, listID)
+	if err != nil {
+		return result, err
+	}
+	listIDs, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return result, err
+	}
+
+	for _, id := range listIDs {
+		list, err := backfillList(ctx, id)
+		if err != nil {
+			return result, fmt.Errorf("list %s: %w", id, err)
+		}
+		if list.Items > 0 {
+			result.Lists++
+		}
+		result.Items += list.Items
+		result.Additions += list.Additions
+		result.Recommendable += list.Recommendable
+	}
+	return result, nil
+}
+
+// backfillTimeline is what a backfill knows about one name on a list
+type backfillTimeline struct {
+	name       string
+	addedCount int         // of the existing record, 0 if there is none
+	recorded   []time.Time // its item_additions
+	found      []time.Time // created_at of its items
+}
+
+// backfillList backfills one list and returns the number of records, additions and recommendable records
+// A name with a record is extended with the items from before its first recorded addition; later items
+// were tracked when they were created. Records with fewer recorded additions than added_count were tracked
+// before item_additions existed and are left alone, as their timeline can't be rebuilt
+func backfillList(ctx context.Context, listID string) (BackfillResult, error) {
+	var result BackfillResult
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return result, err
+	}
+	defer tx.Rollback(ctx)
+
+	synonyms, err := loadSynonyms(ctx, tx, listID)
+	if err != nil {
+		return result, err
+	}
+
+	timelines := map[string]*backfillTimeline{}
+	rows, err := tx.Query(ctx,
+		`SELECT h.name_key, h.item_name, h.added_count, a.added_at
+		FROM item_history h
+		LEFT JOIN item_additions a ON a.list_id = h.list_id AND a.item_name = h.item_name
+		WHERE h.list_id = $1
+		ORDER BY a.added_at ASC`, listID)
+	if err != nil {
+		return result, err
+	}
+	for rows.Next() {
+		var key, name string
+		var addedCount int
+		var addedAt *time.Time
+		if err := rows.Scan(&key, &name, &addedCount, &addedAt); err != nil {
+			rows.Close()
+			return result, err
+		}
+		if timelines[key] == nil {
+			timelines[key] = &backfillTimeline{name: name, addedCount: addedCount}
+		}
+		if addedAt != nil {
+			timelines[key].recorded = append(timelines[key].recorded, *addedAt)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return result, err
+	}
+
+	// Without a record, the first spelling becomes the record's name
+	rows, err = tx.Query(ctx,
+		`SELECT name, created_at FROM items
+		 WHERE list_id = $1
+		 ORDER BY created_at ASC`, listID)
+	if err != nil {
+		return result, err
+	}
+	for rows.Next() {
+		var name string
+		var createdAt time.Time
+		if err := rows.Scan(&name, &createdAt); err != nil {
+			rows.Close()
+			return result, err
+		}
+		key := itemKey(name, synonyms)
+		if key == "" {
+			continue
+		}
+		if timelines[key] == nil {
+			timelines[key] = &backfillTimeline{name: cleanItemName(name)}
+		}
+		timelines[key].found = append(timelines[key].found, createdAt)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return result, err
+	}
+
+	keys := make([]string, 0, len(timelines))
+	for key := range timelines {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		t := timelines[key]
+		if len(t.recorded) < t.addedCount {
+			continue
+		}
+		h, added := backfillRecord(t.name, t.recorded, t.found)
+		if len(added) == 0 {
+			continue
+		}
+
+		// Dismissals and snoozes are kept: old items aren't a reason to show a hidden suggestion again
+		_, err := tx.Exec(ctx,
+			`INSERT INTO item_history (list_id, item_name, name_key, added_count, last_added_at,
+				avg_days_between, interval_ewma, interval_variance, dismissed)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, false)
+			ON CONFLICT (list_id, name_key) DO UPDATE
+			SET added_count = EXCLUDED.added_count, last_added_at = EXCLUDED.last_added_at,
+				avg_days_between = EXCLUDED.avg_days_between, interval_ewma = EXCLUDED.interval_ewma,
+				interval_variance = EXCLUDED.interval_variance`,
+			listID, h.ItemName, key, h.AddedCount, h.LastAddedAt,
+			h.AvgDaysBetween, h.IntervalEWMA, h.IntervalVariance)
+		if err != nil {
+			return result, err
+		}
+		for _, at := range added {
+			_, err := tx.Exec(ctx,
+				"INSERT INTO item_additions (list_id, item_name, added_at) VALUES ($1, $2, $3)",
+				listID, h.ItemName, at)
+			if err != nil {
+				return result, err
+			}
+		}
+		result.Items++
+		result.Additions += len(added)
+		if h.AddedCount >= 2 {
+			result.Recommendable++
+		}
+	}
+
+	return result, tx.Commit(ctx)
+}
+
+// backfillRecord replays a name's timeline through the same interval model as live tracking:
+// its recorded additions plus the items found from before the first of them (and the debounce
+// around it, as an item is created just before its addition is recorded). Both must be sorted.
+// Returns the record and the found items that count as additions; none if the backfill adds nothing
+func backfillRecord(name string, recorded, found []time.Time) (ItemHistory, []time.Time) {
+	if len(recorded) > 0 {
+		cutoff := recorded[0].Add(-readdDebounceMinutes * time.Minute)
+		untracked := []time.Time{}
+		for _, at := range found {
+			if at.Before(cutoff) {
+				untracked = append(untracked, at)
+			}
+		}
+		found = untracked
+	}
+
+	h := ItemHistory{ItemName: name}
+	added := []time.Time{}
+	for _, at := range found {
+		before := h.AddedCount
+		applyAddition(&h, at)
+		if h.AddedCount > before {
+			added = append(added, at)
+		}
+	}
+	for _, at := range recorded {
+		applyAddition(&h, at)
+	}
+	return h, added
+}
+
+// runBackfillHistory is the backfill-history admin command
+func runBackfillHistory(args []string) error {
+	fs := flag.NewFlagSet("backfill-history", flag.ContinueOnError)
+	listID := fs.String("list", "", "only backfill this list")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	result, err := backfillHistory(context.Background(), *listID)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Backfilled %d items (%d additions, %d can be suggested) on %d lists\n",
+		result.Items, result.Additions, result.Recommendable, result.Lists)
+	fmt.Println(result.Note)
+	return nil
+}
+
+// BackfillHistory handles POST /api/admin/backfill-history - same as the backfill-history command
+// Requires "Authorization: Bearer $ADMIN_TOKEN"; ?list_id= limits it to one list
+func BackfillHistory(w http.ResponseWriter, r *http.Request) {
+	if !isAdmin(r) {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	result, err := backfillHistory(context.Background(), r.URL.Query().Get("list_id"))
+	if err != nil {
+		http.Error(w, "Failed to backfill history", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// isAdmin reports whether the request carries the ADMIN_TOKEN (admin endpoints are disabled without one)
+func isAdmin(r *http.Request) bool {
+	adminToken := os.Getenv("ADMIN_TOKEN")
+	token := bearerToken(r)
+	return adminToken != "" && token != "" &&
+		subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) == 1
+}
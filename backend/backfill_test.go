@@ -0,0 +1,86 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIsAdmin(t *testing.T) {
+	tests := []struct {
+		name   string
+		token  string
+		header string
+		want   bool
+	}{
+		{"matching token", "s3cret", "Bearer s3cret", true},
+		{"wrong token", "s3cret", "Bearer guess", false},
+		{"prefix of the token", "s3cret", "Bearer s3c", false},
+		{"no header", "s3cret", "", false},
+		{"not a bearer token", "s3cret", "Basic s3cret", false},
+		{"admin endpoints disabled", "", "Bearer ", false},
+		{"disabled, any token", "", "Bearer anything", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ADMIN_TOKEN", tt.token)
+			r := httptest.NewRequest("POST", "/api/admin/backfill-history", nil)
+			if tt.header != "" {
+				r.Header.Set("Authorization", tt.header)
+			}
+			if got := isAdmin(r); got != tt.want {
+				t.Errorf("isAdmin = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackfillRecord(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	day := func(d int) time.Time { return start.AddDate(0, 0, d) }
+
+	tests := []struct {
+		name      string
+		recorded  []time.Time
+		found     []time.Time
+		wantCount int
+		wantAdded int
+		wantDays  float64 // expected interval, 0 for none
+	}{
+		{"single item", nil, []time.Time{day(0)}, 1, 1, 0},
+		{"checked copies give an interval", nil, []time.Time{day(0), day(7), day(14)}, 3, 3, 7},
+		{"copies created together count once", nil, []time.Time{day(0), day(0).Add(time.Minute)}, 1, 1, 0},
+		{"older items extend a tracked record", []time.Time{day(14), day(21)}, []time.Time{day(0), day(7), day(14), day(21)}, 4, 2, 7},
+		{"item of the first recorded addition isn't counted twice", []time.Time{day(7)}, []time.Time{day(7).Add(-time.Second)}, 1, 0, 0},
+		{"nothing older than the tracked record", []time.Time{day(0), day(7)}, []time.Time{day(7)}, 2, 0, 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, added := backfillRecord("Milk", tt.recorded, tt.found)
+			if h.AddedCount != tt.wantCount {
+				t.Errorf("AddedCount = %d, want %d", h.AddedCount, tt.wantCount)
+			}
+			if len(added) != tt.wantAdded {
+				t.Errorf("added %d items, want %d", len(added), tt.wantAdded)
+			}
+			if got := expectedInterval(h); got != tt.wantDays {
+				t.Errorf("expectedInterval = %v, want %v", got, tt.wantDays)
+			}
+		})
+	}
+}
+
+func TestBackfillRecordIsRepeatable(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	found := []time.Time{start, start.AddDate(0, 0, 5), start.AddDate(0, 0, 10)}
+
+	first, added := backfillRecord("Bread", nil, found)
+	again, addedAgain := backfillRecord("Bread", added, found)
+	if len(addedAgain) != 0 {
+		t.Errorf("second run added %d items, want 0", len(addedAgain))
+	}
+	if again.AddedCount != first.AddedCount || !again.LastAddedAt.Equal(first.LastAddedAt) {
+		t.Errorf("second run = %d additions at %v, want %d at %v",
+			again.AddedCount, again.LastAddedAt, first.AddedCount, first.LastAddedAt)
+	}
+}
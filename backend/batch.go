@@ -0,0 +1,303 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Most operations in one batch request
+const maxBatchOperations = 500
+
+// batchOperation is one step of a batch: "create" (name, section_id or category, parent_id),
+// "update" (id and any of name, checked, sort_order, section_id, parent_id) or "delete" (id)
+type batchOperation struct {
+	Op        string   `json:"op"`
+	ID        string   `json:"id"`
+	Name      *string  `json:"name"`
+	Checked   *bool    `json:"checked"`
+	SortOrder *float64 `json:"sort_order"`
+	SectionID *string  `json:"section_id"`
+	ParentID  *string  `json:"parent_id"`
+	Category  string   `json:"category"`
+}
+
+func (op batchOperation) update() itemUpdate {
+	return itemUpdate{Checked: op.Checked, Name: op.Name, SortOrder: op.SortOrder, SectionID: op.SectionID, ParentID: op.ParentID}
+}
+
+// batchResult is the outcome of one operation, in the order of the request
+type batchResult struct {
+	Op     string `json:"op"`
+	Status int    `json:"status"` // what the single-item endpoint would have answered
+	ID     string `json:"id"`
+	Item   *Item  `json:"item,omitempty"`
+}
+
+// validate checks an operation before anything is written
+func (op batchOperation) validate() error {
+	switch op.Op {
+	case "create":
+		if op.Name == nil || *op.Name == "" {
+			return errors.New("Name is required")
+		}
+		if op.Name != nil && len(*op.Name) > maxItemNameLength {
+			return fmt.Errorf("Item name must be %d characters or less", maxItemNameLength)
+		}
+	case "update":
+		if op.ID == "" {
+			return errors.New("Item ID is required")
+		}
+		if op.Name != nil && len(*op.Name) > maxItemNameLength {
+			return fmt.Errorf("Item name must be %d characters or less", maxItemNameLength)
+		}
+		if op.update().empty() {
+			return errors.New("No fields to update")
+		}
+	case "delete":
+		if op.ID == "" {
+			return errors.New("Item ID is required")
+		}
+	default:
+		return errors.New("op must be create, update or delete")
+	}
+	return nil
+}
+
+// BatchItems handles POST /api/lists/{listId}/items/batch - applies create, update and delete
+// operations in one transaction: either all of them succeed or none does
+func BatchItems(w http.ResponseWriter, r *http.Request) {
+	listID := r.PathValue("listId")
+
+	var input struct {
+		Operations []batchOperation `json:"operations"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if len(input.Operations) == 0 {
+		http.Error(w, "operations is required", http.StatusBadRequest)
+		return
+	}
+	if len(input.Operations) > maxBatchOperations {
+		http.Error(w, fmt.Sprintf("At most %d operations per batch", maxBatchOperations), http.StatusBadRequest)
+		return
+	}
+	for i, op := range input.Operations {
+		if err := op.validate(); err != nil {
+			http.Error(w, fmt.Sprintf("Operation %d: %v", i, err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	ctx := context.Background()
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		http.Error(w, "Failed to apply batch", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the list so concurrent batches and appends don't interleave
+	maxOrder, err := lockListForAppend(ctx, tx, listID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, "List not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to apply batch", http.StatusInternalServerError)
+		return
+	}
+	synonyms, err := loadSynonyms(ctx, tx, listID)
+	if err != nil {
+		http.Error(w, "Failed to apply batch", http.StatusInternalServerError)
+		return
+	}
+
+	results := make([]batchResult, len(input.Operations))
+	for i, op := range input.Operations {
+		result := batchResult{Op: op.Op, ID: op.ID}
+		switch op.Op {
+		case "create":
+			item, status, err := batchCreate(ctx, tx, listID, op, synonyms, &maxOrder)
+			if batchError(w, i, err) {
+				return
+			}
+			if err != nil {
+				http.Error(w, fmt.Sprintf("Operation %d: Failed to create item", i), http.StatusInternalServerError)
+				return
+			}
+			result.Status, result.ID, result.Item = status, item.ID, &item
+
+		case "update":
+			item, err := updateItem(ctx, tx, listID, op.ID, op.update())
+			if batchError(w, i, err) {
+				return
+			}
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, fmt.Sprintf("Operation %d: Item not found", i), http.StatusNotFound)
+				return
+			}
+			if err != nil {
+				http.Error(w, fmt.Sprintf("Operation %d: Failed to update item", i), http.StatusInternalServerError)
+				return
+			}
+			result.Status, result.Item = http.StatusOK, &item
+
+		case "delete":
+			// Sub-items are deleted with their parent, as with DELETE /items/{id}
+			var parentID *string
+			err := tx.QueryRow(ctx,
+				"DELETE FROM items WHERE id = $1 AND list_id = $2 RETURNING parent_id::text", op.ID, listID).Scan(&parentID)
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, fmt.Sprintf("Operation %d: Item not found", i), http.StatusNotFound)
+				return
+			}
+			if err == nil {
+				err = syncParentChecked(ctx, tx, parentID)
+			}
+			if err != nil {
+				http.Error(w, fmt.Sprintf("Operation %d: Failed to delete item", i), http.StatusInternalServerError)
+				return
+			}
+			result.Status = http.StatusNoContent
+		}
+		results[i] = result
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		http.Error(w, "Failed to apply batch", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"results": results})
+}
+
+// batchCreate adds an item like CreateItem: an item that's already on the list is unchecked instead
+// Returns the item and 201 Created, or 200 OK for an existing item
+func batchCreate(ctx context.Context, tx pgx.Tx, listID string, op batchOperation, synonyms map[string]string, maxOrder *float64) (Item, int, error) {
+	var sectionID, parentID string
+	if op.SectionID != nil {
+		sectionID = *op.SectionID
+	}
+	if op.ParentID != nil {
+		parentID = *op.ParentID
+	}
+	section, err := resolveSection(ctx, tx, listID, sectionID, op.Category)
+	if err != nil {
+		return Item{}, 0, err
+	}
+	place := itemPlace{SectionID: section}
+	if parentID != "" {
+		if place, err = resolveParent(ctx, tx, listID, "", parentID); err != nil {
+			return Item{}, 0, err
+		}
+	}
+
+	name := *op.Name
+	existing, err := findItemByKey(ctx, tx, listID, place.ParentID, itemKey(name, synonyms), synonyms)
+	if err != nil {
+		return Item{}, 0, err
+	}
+	if existing != nil {
+		if existing.Checked {
+			existing.Checked = false
+			if _, err := tx.Exec(ctx, "UPDATE items SET checked = false WHERE id = $1", existing.ID); err != nil {
+				return Item{}, 0, err
+			}
+			if err := checkItem(ctx, tx, *existing); err != nil {
+				return Item{}, 0, err
+			}
+			if err := trackItemAddition(ctx, tx, listID, existing.Name); err != nil {
+				return Item{}, 0, err
+			}
+		}
+		return *existing, http.StatusOK, nil
+	}
+
+	*maxOrder += rankStep
+	item, err := insertItem(ctx, tx, listID, itemSeed{Name: name, SectionID: place.SectionID, ParentID: place.ParentID}, *maxOrder)
+	if err != nil {
+		return Item{}, 0, err
+	}
+	if err := syncParentChecked(ctx, tx, item.ParentID); err != nil {
+		return Item{}, 0, err
+	}
+	if err := trackItemAddition(ctx, tx, listID, name); err != nil {
+		return Item{}, 0, err
+	}
+	return item, http.StatusCreated, nil
+}
+
+// batchError writes the error response for a section or parent of operation i that can't be used
+// Returns false for any other error, which the caller handles
+func batchError(w http.ResponseWriter, i int, err error) bool {
+	switch {
+	case errors.Is(err, errSectionNotFound):
+		http.Error(w, fmt.Sprintf("Operation %d: Section not found", i), http.StatusNotFound)
+	case errors.Is(err, errParentNotFound):
+		http.Error(w, fmt.Sprintf("Operation %d: Parent item not found", i), http.StatusNotFound)
+	case errors.Is(err, errNestingTooDeep):
+		http.Error(w, fmt.Sprintf("Operation %d: Sub-items can't have sub-items of their own", i), http.StatusBadRequest)
+	default:
+		return false
+	}
+	return true
+}
+
+// ClearCheckedItems handles POST /api/lists/{listId}/items/clear-checked - deletes all checked items
+func ClearCheckedItems(w http.ResponseWriter, r *http.Request) {
+	bulkItemChange(w, r.PathValue("listId"), "deleted",
+		"DELETE FROM items WHERE list_id = $1 AND checked")
+}
+
+// UncheckAllItems handles POST /api/lists/{listId}/items/uncheck-all - unchecks every item
+func UncheckAllItems(w http.ResponseWriter, r *http.Request) {
+	bulkItemChange(w, r.PathValue("listId"), "updated",
+		"UPDATE items SET checked = false WHERE list_id = $1 AND checked")
+}
+
+// DeleteAllItems handles DELETE /api/lists/{listId}/items - empties the list (its sections are kept)
+func DeleteAllItems(w http.ResponseWriter, r *http.Request) {
+	bulkItemChange(w, r.PathValue("listId"), "deleted",
+		"DELETE FROM items WHERE list_id = $1")
+}
+
+// bulkItemChange runs one statement over a list's items and responds with the number of rows, e.g. {"deleted": 3}
+func bulkItemChange(w http.ResponseWriter, listID, countField, query string) {
+	ctx := context.Background()
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		http.Error(w, "Failed to update items", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := lockListForAppend(ctx, tx, listID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, "List not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to update items", http.StatusInternalServerError)
+		return
+	}
+
+	tag, err := tx.Exec(ctx, query, listID)
+	if err != nil {
+		http.Error(w, "Failed to update items", http.StatusInternalServerError)
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		http.Error(w, "Failed to update items", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int64{countField: tag.RowsAffected()})
+}
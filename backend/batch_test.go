@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBatchOperationValidate(t *testing.T) {
+	name := func(s string) *string { return &s }
+	checked := true
+	tests := []struct {
+		name    string
+		op      batchOperation
+		wantErr string
+	}{
+		{"create", batchOperation{Op: "create", Name: name("Milk")}, ""},
+		{"create without a name", batchOperation{Op: "create"}, "Name is required"},
+		{"create with an empty name", batchOperation{Op: "create", Name: name("")}, "Name is required"},
+		{"create with a long name", batchOperation{Op: "create", Name: name(strings.Repeat("x", maxItemNameLength+1))},
+			fmt.Sprintf("Item name must be %d characters or less", maxItemNameLength)},
+		{"update", batchOperation{Op: "update", ID: "1", Checked: &checked}, ""},
+		{"update without an ID", batchOperation{Op: "update", Checked: &checked}, "Item ID is required"},
+		{"update without fields", batchOperation{Op: "update", ID: "1"}, "No fields to update"},
+		{"update with a long name", batchOperation{Op: "update", ID: "1", Name: name(strings.Repeat("x", maxItemNameLength+1))},
+			fmt.Sprintf("Item name must be %d characters or less", maxItemNameLength)},
+		{"delete", batchOperation{Op: "delete", ID: "1"}, ""},
+		{"delete without an ID", batchOperation{Op: "delete"}, "Item ID is required"},
+		{"unknown op", batchOperation{Op: "rename", ID: "1"}, "op must be create, update or delete"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.op.validate()
+			got := ""
+			if err != nil {
+				got = err.Error()
+			}
+			if got != tt.wantErr {
+				t.Errorf("validate() = %q, want %q", got, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBatchItemsRejectsBeforeWriting(t *testing.T) {
+	tooMany := `{"operations": [` + strings.TrimSuffix(strings.Repeat(`{"op": "delete", "id": "1"},`, maxBatchOperations+1), ",") + `]}`
+	tests := []struct {
+		name, body, want string
+	}{
+		{"invalid JSON", `{`, "Invalid JSON"},
+		{"no operations", `{"operations": []}`, "operations is required"},
+		{"too many operations", tooMany, fmt.Sprintf("At most %d operations per batch", maxBatchOperations)},
+		{"one invalid operation", `{"operations": [{"op": "create", "name": "Milk"}, {"op": "update", "id": "1"}]}`,
+			"Operation 1: No fields to update"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/api/lists/abc/items/batch", strings.NewReader(tt.body))
+			r.SetPathValue("listId", "abc")
+			w := httptest.NewRecorder()
+			BatchItems(w, r)
+			if w.Code != http.StatusBadRequest || strings.TrimSpace(w.Body.String()) != tt.want {
+				t.Errorf("got %d %q, want 400 %q", w.Code, strings.TrimSpace(w.Body.String()), tt.want)
+			}
+		})
+	}
+}
+
+func TestBatchError(t *testing.T) {
+	tests := []struct {
+		err     error
+		handled bool
+		status  int
+		body    string
+	}{
+		{errSectionNotFound, true, http.StatusNotFound, "Operation 2: Section not found"},
+		{fmt.Errorf("create: %w", errParentNotFound), true, http.StatusNotFound, "Operation 2: Parent item not found"},
+		{errNestingTooDeep, true, http.StatusBadRequest, "Operation 2: Sub-items can't have sub-items of their own"},
+		{fmt.Errorf("other"), false, http.StatusOK, ""},
+		{nil, false, http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		if got := batchError(w, 2, tt.err); got != tt.handled {
+			t.Errorf("batchError(%v) = %t, want %t", tt.err, got, tt.handled)
+		}
+		if w.Code != tt.status || strings.TrimSpace(w.Body.String()) != tt.body {
+			t.Errorf("batchError(%v) wrote %d %q, want %d %q", tt.err, w.Code, w.Body.String(), tt.status, tt.body)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// Admin commands run instead of the HTTP server, e.g. `go run . eval-recommendations -window 14`
+var commands = map[string]struct {
+	usage string
+	run   func(args []string) error
+}{
+	"eval-recommendations": {
+		usage: "Replay item_history and report precision/recall per recommendation strategy",
+		run:   runEvalRecommendations,
+	},
+	"normalize-history": {
+		usage: "Recompute normalized item_history keys and merge duplicate records",
+		run:   runNormalizeHistory,
+	},
+	"refresh-popularity": {
+		usage: "Rebuild the anonymized item popularity aggregate now",
+		run:   runRefreshPopularity,
+	},
+	"backfill-history": {
+		usage: "Create item_history for items added before history was tracked",
+		run:   runBackfillHistory,
+	},
+}
+
+// runCommand executes an admin command and exits
+func runCommand(args []string) {
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown command %q. Available commands:\n", args[0])
+		for name, c := range commands {
+			fmt.Fprintf(os.Stderr, "  %-24s %s\n", name, c.usage)
+		}
+		os.Exit(2)
+	}
+
+	if err := cmd.run(args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", args[0], err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+)
+
+// Co-occurrence settings
+const (
+	tripWindowHours    = 3 // additions less than this apart belong to the same trip
+	minCooccurrences   = 2 // pairs seen fewer times are noise
+	maxRelatedItems    = 5
+	defaultRelatedRank = "lift"
+)
+
+// RelatedItem is an item that is often added together with another one
+// Confidence is P(related | item), lift is how much more likely that is than the related item on its own
+type RelatedItem struct {
+	Name       string  `json:"name"`
+	Count      int     `json:"count"`
+	Confidence float64 `json:"confidence"`
+	Lift       float64 `json:"lift"`
+}
+
+// recordCooccurrence counts a pair for every other item added during the current trip and updates the trip counter
+// Must run before the item's own last_added_at is updated. Records without additions (a dismissed
+// popular suggestion) are skipped, their last_added_at is when they were hidden
+func recordCooccurrence(ctx context.Context, q querier, listID, itemName string) error {
+	// Pairs are only counted the first time the item is added in a trip,
+	// so re-adding it a minute later doesn't inflate the counts
+	_, err := q.Exec(ctx,
+		`INSERT INTO item_cooccurrence (list_id, item_a, item_b, count, last_seen_at)
+		SELECT $1, pair.a, pair.b, 1, NOW()
+		FROM item_history h,
+			LATERAL (VALUES ($2::varchar, h.item_name), (h.item_name, $2::varchar)) AS pair(a, b)
+		WHERE h.list_id = $1
+			AND h.item_name <> $2 AND h.added_count > 0
+			AND h.last_added_at >= NOW() - make_interval(hours => $3)
+			AND NOT EXISTS (
+				SELECT 1 FROM item_history self
+				WHERE self.list_id = $1 AND self.item_name = $2 AND self.added_count > 0
+					AND self.last_added_at >= NOW() - make_interval(hours => $3)
+			)
+		ON CONFLICT (list_id, item_a, item_b) DO UPDATE
+		SET count = item_cooccurrence.count + 1, last_seen_at = NOW()`,
+		listID, itemName, tripWindowHours)
+	if err != nil {
+		return err
+	}
+
+	// A new trip starts after a break of tripWindowHours
+	_, err = q.Exec(ctx,
+		`INSERT INTO list_trips (list_id, trip_count, last_activity_at)
+		VALUES ($1, 1, NOW())
+		ON CONFLICT (list_id) DO UPDATE
+		SET trip_count = list_trips.trip_count +
+				CASE WHEN list_trips.last_activity_at < NOW() - make_interval(hours => $2) THEN 1 ELSE 0 END,
+			last_activity_at = NOW()`,
+		listID, tripWindowHours)
+	return err
+}
+
+// GetRelatedItems handles GET /api/lists/{listId}/items/{id}/related - "often bought together" suggestions
+// Unchecked items on the list are left out. ?rank=lift (default) or ?rank=confidence
+func GetRelatedItems(w http.ResponseWriter, r *http.Request) {
+	listID := r.PathValue("listId")
+	id := r.PathValue("id")
+	if listID == "" || id == "" {
+		http.Error(w, "List ID and Item ID are required", http.StatusBadRequest)
+		return
+	}
+
+	rank := r.URL.Query().Get("rank")
+	if rank == "" {
+		rank = defaultRelatedRank
+	}
+	if rank != "lift" && rank != "confidence" {
+		http.Error(w, "rank must be lift or confidence", http.StatusBadRequest)
+		return
+	}
+
+	var name string
+	err := DB.QueryRow(context.Background(),
+		"SELECT name FROM items WHERE id::text = $1 AND list_id = $2", id, listID).Scan(&name)
+	if err != nil {
+		http.Error(w, "Item not found", http.StatusNotFound)
+		return
+	}
+
+	related, err := relatedItems(context.Background(), listID, name, rank)
+	if err != nil {
+		// Tables might not exist yet - no suggestions
+		related = []RelatedItem{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(related)
+}
+
+// relatedItems ranks the items that co-occur with itemName on a list
+func relatedItems(ctx context.Context, listID, itemName, rank string) ([]RelatedItem, error) {
+	synonyms, err := loadSynonyms(ctx, DB, listID)
+	if err != nil {
+		return nil, err
+	}
+	onList, err := uncheckedItemKeys(ctx, DB, listID, synonyms)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := DB.Query(ctx,
+		`SELECT c.item_b, hb.name_key, c.count, ha.added_count, hb.added_count, t.trip_count
+		FROM item_cooccurrence c
+		JOIN item_history ha ON ha.list_id = c.list_id AND ha.item_name = c.item_a
+		JOIN item_history hb ON hb.list_id = c.list_id AND hb.item_name = c.item_b
+		JOIN list_trips t ON t.list_id = c.list_id
+		WHERE c.list_id = $1 AND ha.name_key = $2 AND c.count >= $3`,
+		listID, itemKey(itemName, synonyms), minCooccurrences)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	related := []RelatedItem{}
+	for rows.Next() {
+		var item RelatedItem
+		var key string
+		var countA, countB, trips int
+		if err := rows.Scan(&item.Name, &key, &item.Count, &countA, &countB, &trips); err != nil {
+			return nil, err
+		}
+		if !onList[key] && scoreRelated(&item, countA, countB, trips) {
+			related = append(related, item)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return rankRelated(related, rank), nil
+}
+
+// scoreRelated sets an item's confidence and lift from the pair count, both items' addition counts
+// and the list's trip count. Returns false if they aren't bought together more often than by chance
+func scoreRelated(item *RelatedItem, countA, countB, trips int) bool {
+	if countA == 0 || countB == 0 || trips == 0 {
+		return false
+	}
+
+	// Each addition is treated as one trip containing the item
+	item.Confidence = min(float64(item.Count)/float64(countA), 1)
+	support := min(float64(countB)/float64(trips), 1)
+	item.Lift = item.Confidence / support
+
+	// A lift of 1 or less means they're not bought together more often than by chance
+	return item.Lift > 1
+}
+
+// rankRelated sorts related items by lift (or confidence first, for rank "confidence") and keeps the top ones
+func rankRelated(related []RelatedItem, rank string) []RelatedItem {
+	sort.Slice(related, func(i, j int) bool {
+		if rank == "confidence" && related[i].Confidence != related[j].Confidence {
+			return related[i].Confidence > related[j].Confidence
+		}
+		if related[i].Lift != related[j].Lift {
+			return related[i].Lift > related[j].Lift
+		}
+		return related[i].Count > related[j].Count
+	})
+	if len(related) > maxRelatedItems {
+		related = related[:maxRelatedItems]
+	}
+	return related
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestScoreRelated(t *testing.T) {
+	tests := []struct {
+		name                 string
+		count                int
+		countA, countB, trip int
+		confidence, lift     float64
+		ok                   bool
+	}{
+		// Butter came along on 4 of 5 milk trips, but only on 8 of 40 trips overall
+		{"bought together", 4, 5, 8, 40, 0.8, 4, true},
+		// Bread is on every other trip anyway
+		{"as often as by chance", 2, 4, 20, 40, 0.5, 1, false},
+		{"less often than by chance", 1, 4, 30, 40, 0.25, 1.0 / 3, false},
+		// Counts can drift apart (e.g. after merging records); shares are capped at 1
+		{"more pairs than additions", 6, 5, 2, 10, 1, 5, true},
+		{"no trips", 2, 3, 3, 0, 0, 0, false},
+		{"item never added", 2, 0, 3, 10, 0, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := RelatedItem{Name: "x", Count: tt.count}
+			ok := scoreRelated(&item, tt.countA, tt.countB, tt.trip)
+			if ok != tt.ok {
+				t.Errorf("ok = %t, want %t", ok, tt.ok)
+			}
+			if math.Abs(item.Confidence-tt.confidence) > 1e-9 || math.Abs(item.Lift-tt.lift) > 1e-9 {
+				t.Errorf("confidence %v, lift %v, want %v and %v", item.Confidence, item.Lift, tt.confidence, tt.lift)
+			}
+		})
+	}
+}
+
+func TestRankRelated(t *testing.T) {
+	items := func() []RelatedItem {
+		return []RelatedItem{
+			{Name: "a", Count: 2, Confidence: 0.9, Lift: 1.5},
+			{Name: "b", Count: 3, Confidence: 0.4, Lift: 4},
+			{Name: "c", Count: 5, Confidence: 0.6, Lift: 2},
+			{Name: "d", Count: 9, Confidence: 0.6, Lift: 2},
+			{Name: "e", Count: 2, Confidence: 0.3, Lift: 1.2},
+			{Name: "f", Count: 2, Confidence: 0.2, Lift: 1.1},
+		}
+	}
+	tests := []struct {
+		rank string
+		want []string
+	}{
+		{"lift", []string{"b", "d", "c", "a", "e"}},
+		{"confidence", []string{"a", "d", "c", "b", "e"}},
+	}
+	for _, tt := range tests {
+		got := []string{}
+		for _, item := range rankRelated(items(), tt.rank) {
+			got = append(got, item.Name)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("rank %s: %v, want %v", tt.rank, got, tt.want)
+		}
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"log"
 	"os"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -12,6 +14,14 @@ import (
 // A "pool" manages multiple connections efficiently - you don't open/close for each query
 var DB *pgxpool.Pool
 
+// querier is satisfied by both the pool and a transaction (pgx.Tx)
+// Helpers that take a querier can run standalone or as part of a larger transaction
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
 // ConnectDB establishes the database connection
 func ConnectDB() {
 	// Get the connection string from environment variable
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	_ "embed"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"math"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/srwiley/oksvg"
+	"github.com/srwiley/rasterx"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// Twemoji v14.0.2 SVGs (https://github.com/twitter/twemoji), CC-BY 4.0 - see assets/TWEMOJI-LICENSE
+// Files are named after their code points, e.g. 1f6d2.svg or 1f3c3-1f3fb-200d-2640-fe0f.svg
+//
+//go:embed assets/twemoji.zip
+var twemojiZip []byte
+
+var (
+	twemojiOnce  sync.Once
+	twemojiFiles map[string]*zip.File
+)
+
+// Code points with special meaning in emoji sequences
+const (
+	zeroWidthJoiner   = 0x200d
+	variationSelector = 0xfe0f
+)
+
+// loadTwemoji indexes the embedded archive by file name (without .svg)
+func loadTwemoji() map[string]*zip.File {
+	twemojiOnce.Do(func() {
+		twemojiFiles = map[string]*zip.File{}
+		archive, err := zip.NewReader(bytes.NewReader(twemojiZip), int64(len(twemojiZip)))
+		if err != nil {
+			return
+		}
+		for _, f := range archive.File {
+			twemojiFiles[strings.TrimSuffix(f.Name, ".svg")] = f
+		}
+	})
+	return twemojiFiles
+}
+
+// emojiCodePoints formats runes the way Twemoji names its files
+func emojiCodePoints(runes []rune) string {
+	parts := make([]string, len(runes))
+	for i, r := range runes {
+		parts[i] = fmt.Sprintf("%x", r)
+	}
+	return strings.Join(parts, "-")
+}
+
+// emojiAssetCandidates lists the file names an emoji could be stored under, best match first
+// Twemoji drops U+FE0F except in ZWJ sequences, but isn't fully consistent about it,
+// so both spellings are tried. A ZWJ sequence without its own asset falls back to its first emoji
+func emojiAssetCandidates(emoji string) []string {
+	runes := []rune(strings.TrimSpace(emoji))
+	withoutVS := []rune{}
+	for _, r := range runes {
+		if r != variationSelector {
+			withoutVS = append(withoutVS, r)
+		}
+	}
+
+	candidates := []string{}
+	if strings.ContainsRune(emoji, zeroWidthJoiner) {
+		candidates = append(candidates, emojiCodePoints(runes), emojiCodePoints(withoutVS))
+		for i, r := range withoutVS {
+			if r == zeroWidthJoiner {
+				candidates = append(candidates, emojiCodePoints(withoutVS[:i]))
+				break
+			}
+		}
+	} else {
+		candidates = append(candidates, emojiCodePoints(withoutVS), emojiCodePoints(runes))
+	}
+	return candidates
+}
+
+// emojiAsset finds the bundled file for an emoji (including ZWJ sequences, skin tones and flags)
+func emojiAsset(emoji string) (*zip.File, bool) {
+	files := loadTwemoji()
+	for _, name := range emojiAssetCandidates(emoji) {
+		if f, ok := files[name]; ok {
+			return f, true
+		}
+	}
+	return nil, false
+}
+
+// emojiSVG returns the bundled SVG for an emoji
+func emojiSVG(emoji string) ([]byte, bool) {
+	f, ok := emojiAsset(emoji)
+	if !ok {
+		return nil, false
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, false
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	return data, err == nil
+}
+
+// renderEmoji rasterizes an emoji to a size x size image
+// Returns false if there is no asset for it
+func renderEmoji(emoji string, size int) (image.Image, bool) {
+	data, ok := emojiSVG(emoji)
+	if !ok {
+		return nil, false
+	}
+	icon, err := oksvg.ReadIconStream(bytes.NewReader(data), oksvg.WarnErrorMode)
+	if err != nil {
+		return nil, false
+	}
+	icon.SetTarget(0, 0, float64(size), float64(size))
+
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	scanner := rasterx.NewScannerGV(size, size, img, img.Bounds())
+	icon.Draw(rasterx.NewDasher(size, size, scanner), 1)
+	return img, true
+}
+
+// monogram returns up to two initials of a list name, e.g. "Weekly Groceries" -> "WG"
+func monogram(name string) string {
+	letters := []rune{}
+	for _, word := range strings.Fields(name) {
+		for _, r := range word {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				letters = append(letters, unicode.ToUpper(r))
+				break
+			}
+		}
+		if len(letters) == 2 {
+			break
+		}
+	}
+	return string(letters)
+}
+
+var (
+	monogramFontOnce sync.Once
+	monogramFont     *opentype.Font
+)
+
+// drawMonogram draws text centered into rect, sized to fill about half of it
+func drawMonogram(img *image.RGBA, rect image.Rectangle, text string, fg color.Color) {
+	if text == "" {
+		return
+	}
+	monogramFontOnce.Do(func() {
+		monogramFont, _ = opentype.Parse(gobold.TTF)
+	})
+	if monogramFont == nil {
+		return
+	}
+
+	// Two letters get a smaller font so they fit the same width
+	height := float64(rect.Dy()) * 0.5
+	if len([]rune(text)) > 1 {
+		height = float64(rect.Dy()) * 0.4
+	}
+	face, err := opentype.NewFace(monogramFont, &opentype.FaceOptions{Size: height, DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		return
+	}
+	defer face.Close()
+
+	d := &font.Drawer{Dst: img, Src: image.NewUniform(fg), Face: face}
+	bounds, _ := d.BoundString(text)
+	width := bounds.Max.X - bounds.Min.X
+	textHeight := bounds.Max.Y - bounds.Min.Y
+	d.Dot = fixed.Point26_6{
+		X: fixed.I(rect.Min.X) + (fixed.I(rect.Dx())-width)/2 - bounds.Min.X,
+		Y: fixed.I(rect.Min.Y) + (fixed.I(rect.Dy())-textHeight)/2 - bounds.Min.Y,
+	}
+	d.DrawString(text)
+}
+
+// contrastColor returns black or white, whichever has the higher WCAG contrast ratio against bg
+func contrastColor(bg color.RGBA) color.RGBA {
+	black := color.RGBA{0, 0, 0, 255}
+	white := color.RGBA{255, 255, 255, 255}
+	if contrastRatio(bg, black) >= contrastRatio(bg, white) {
+		return black
+	}
+	return white
+}
+
+// contrastRatio is the WCAG 2 contrast ratio between two colours, from 1 to 21
+func contrastRatio(a, b color.RGBA) float64 {
+	la, lb := relativeLuminance(a), relativeLuminance(b)
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
+
+// relativeLuminance of an sRGB colour as defined by WCAG 2
+func relativeLuminance(c color.RGBA) float64 {
+	linear := func(v uint8) float64 {
+		s := float64(v) / 255
+		if s <= 0.03928 {
+			return s / 12.92
+		}
+		return math.Pow((s+0.055)/1.055, 2.4)
+	}
+	return 0.2126*linear(c.R) + 0.7152*linear(c.G) + 0.0722*linear(c.B)
+}
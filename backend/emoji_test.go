@@ -0,0 +1,110 @@
+package main
+
+import (
+	"image/color"
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestEmojiAssetCandidates(t *testing.T) {
+	tests := []struct {
+		emoji string
+		want  []string
+	}{
+		{"🛒", []string{"1f6d2", "1f6d2"}},
+		{" 🛒 ", []string{"1f6d2", "1f6d2"}},
+		{"❤️", []string{"2764", "2764-fe0f"}},
+		{"🇩🇪", []string{"1f1e9-1f1ea", "1f1e9-1f1ea"}},
+		{"👍🏽", []string{"1f44d-1f3fd", "1f44d-1f3fd"}},
+		{"👩‍💻", []string{"1f469-200d-1f4bb", "1f469-200d-1f4bb", "1f469"}},
+		{"🏳️‍🌈", []string{"1f3f3-fe0f-200d-1f308", "1f3f3-200d-1f308", "1f3f3"}},
+	}
+	for _, tt := range tests {
+		if got := emojiAssetCandidates(tt.emoji); !slices.Equal(got, tt.want) {
+			t.Errorf("emojiAssetCandidates(%q) = %v, want %v", tt.emoji, got, tt.want)
+		}
+	}
+}
+
+func TestEmojiAsset(t *testing.T) {
+	tests := []struct {
+		emoji string
+		found bool
+	}{
+		{"🛒", true},
+		{"🥦", true},
+		{"❤️", true},
+		{"❤", true},
+		{"🇩🇪", true},
+		{"👍🏽", true},
+		{"🏳️‍🌈", true},
+		{"🏃🏻‍♀️", true},
+		{"x", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if _, found := emojiAsset(tt.emoji); found != tt.found {
+			t.Errorf("emojiAsset(%q) found = %v, want %v", tt.emoji, found, tt.found)
+		}
+	}
+}
+
+func TestMonogram(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Weekly Groceries", "WG"},
+		{"groceries", "G"},
+		{"Party at Anna's house", "PA"},
+		{"  ", ""},
+		{"🛒 Groceries", "G"},
+		{"- Baumarkt - Liste", "BL"},
+		{"2024 trip", "2T"},
+		{"éclairs", "É"},
+	}
+	for _, tt := range tests {
+		if got := monogram(tt.name); got != tt.want {
+			t.Errorf("monogram(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestContrastColor(t *testing.T) {
+	black := color.RGBA{0, 0, 0, 255}
+	white := color.RGBA{255, 255, 255, 255}
+	tests := []struct {
+		bg   color.RGBA
+		want color.RGBA
+	}{
+		{white, black},
+		{black, white},
+		{color.RGBA{0xff, 0xeb, 0x3b, 255}, black}, // yellow
+		{color.RGBA{0x1e, 0x3a, 0x8a, 255}, white}, // dark blue
+		{color.RGBA{0x4c, 0xaf, 0x50, 255}, black}, // mid green
+	}
+	for _, tt := range tests {
+		if got := contrastColor(tt.bg); got != tt.want {
+			t.Errorf("contrastColor(%v) = %v, want %v", tt.bg, got, tt.want)
+		}
+	}
+}
+
+func TestContrastRatio(t *testing.T) {
+	tests := []struct {
+		a, b color.RGBA
+		want float64
+	}{
+		{color.RGBA{0, 0, 0, 255}, color.RGBA{255, 255, 255, 255}, 21},
+		{color.RGBA{255, 255, 255, 255}, color.RGBA{0, 0, 0, 255}, 21},
+		{color.RGBA{0x42, 0xb8, 0x83, 255}, color.RGBA{0x42, 0xb8, 0x83, 255}, 1},
+		{color.RGBA{0x77, 0x77, 0x77, 255}, color.RGBA{255, 255, 255, 255}, 4.48},
+		{color.RGBA{0, 0, 255, 255}, color.RGBA{255, 255, 255, 255}, 8.59},
+	}
+	for _, tt := range tests {
+		if got := contrastRatio(tt.a, tt.b); math.Abs(got-tt.want) > 0.01 {
+			t.Errorf("contrastRatio(%v, %v) = %.3f, want %.2f", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
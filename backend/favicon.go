@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Sizes bundled into favicon.ico
+var faviconSizes = []int{16, 32, 48}
+
+// splashScreen is an iOS launch image for one device (portrait)
+type splashScreen struct {
+	Width, Height int // CSS pixels
+	PixelRatio    int
+}
+
+// splashScreens are the devices apple-touch-startup-image is generated for
+// iOS only shows a splash screen if its size matches the device exactly
+var splashScreens = []splashScreen{
+	{375, 667, 2},   // iPhone SE, 8
+	{414, 736, 3},   // iPhone 8 Plus
+	{375, 812, 3},   // iPhone X, XS, 11 Pro, 12 mini, 13 mini
+	{414, 896, 2},   // iPhone XR, 11
+	{414, 896, 3},   // iPhone XS Max, 11 Pro Max
+	{390, 844, 3},   // iPhone 12, 13, 14
+	{428, 926, 3},   // iPhone 12 Pro Max, 13 Pro Max, 14 Plus
+	{393, 852, 3},   // iPhone 14 Pro, 15, 15 Pro, 16
+	{430, 932, 3},   // iPhone 14 Pro Max, 15 Plus, 15 Pro Max, 16 Plus
+	{402, 874, 3},   // iPhone 16 Pro
+	{440, 956, 3},   // iPhone 16 Pro Max
+	{744, 1133, 2},  // iPad mini
+	{810, 1080, 2},  // iPad 10.2"
+	{820, 1180, 2},  // iPad Air
+	{834, 1194, 2},  // iPad Pro 11"
+	{1024, 1366, 2}, // iPad Pro 12.9"
+}
+
+// Emoji size on a splash screen, relative to the screen's width
+const splashEmojiScale = 0.3
+
+// pixels returns the image size in device pixels
+func (s splashScreen) pixels() (int, int) {
+	return s.Width * s.PixelRatio, s.Height * s.PixelRatio
+}
+
+// media returns the media query that selects the splash screen in a <link>
+func (s splashScreen) media() string {
+	return fmt.Sprintf("(device-width: %dpx) and (device-height: %dpx) and (-webkit-device-pixel-ratio: %d) and (orientation: portrait)",
+		s.Width, s.Height, s.PixelRatio)
+}
+
+// GetListFavicon handles GET /api/lists/{listId}/favicon.ico - a multi-resolution favicon (16, 32, 48 px)
+func GetListFavicon(w http.ResponseWriter, r *http.Request) {
+	src, err := loadIconSource(context.Background(), r.PathValue("listId"))
+	if err != nil {
+		http.Error(w, "List not found", http.StatusNotFound)
+		return
+	}
+
+	largest := faviconSizes[len(faviconSizes)-1]
+	key := src.key(largest, largest, "favicon")
+	key.Format = "ico"
+	serveIcon(w, r, key, "image/x-icon", func() ([]byte, error) {
+		images := make([][]byte, len(faviconSizes))
+		for i, size := range faviconSizes {
+			sized := key
+			sized.Width, sized.Height, sized.Format = size, size, "png"
+			data, err := encodePNG(renderIcon(sized, iconEmojiScale["favicon"]))
+			if err != nil {
+				return nil, err
+			}
+			images[i] = data
+		}
+		return encodeICO(faviconSizes, images)
+	})
+}
+
+// encodeICO packs square PNG images into an .ico file (PNG-compressed entries, supported since Windows Vista)
+func encodeICO(sizes []int, images [][]byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	// ICONDIR: reserved, type 1 = icon, number of images
+	binary.Write(&buf, binary.LittleEndian, [3]uint16{0, 1, uint16(len(images))})
+
+	// ICONDIRENTRY per image; the image data follows all entries
+	offset := 6 + 16*len(images)
+	for i, data := range images {
+		dim := uint8(sizes[i])
+		if sizes[i] >= 256 {
+			dim = 0 // 0 means 256
+		}
+		entry := struct {
+			Width, Height, Colors, Reserved uint8
+			Planes, BitCount                uint16
+			Size, Offset                    uint32
+		}{dim, dim, 0, 0, 1, 32, uint32(len(data)), uint32(offset)}
+		if err := binary.Write(&buf, binary.LittleEndian, entry); err != nil {
+			return nil, err
+		}
+		offset += len(data)
+	}
+	for _, data := range images {
+		buf.Write(data)
+	}
+	return buf.Bytes(), nil
+}
+
+// GetListSplashScreens handles GET /api/lists/{listId}/splash-screens
+// Returns the apple-touch-startup-image links ({href, media}) for the frontend to add to the page
+func GetListSplashScreens(w http.ResponseWriter, r *http.Request) {
+	listID := r.PathValue("listId")
+	if _, err := loadIconSource(context.Background(), listID); err != nil {
+		http.Error(w, "List not found", http.StatusNotFound)
+		return
+	}
+
+	links := make([]map[string]string, len(splashScreens))
+	for i, s := range splashScreens {
+		width, height := s.pixels()
+		links[i] = map[string]string{
+			"href":  fmt.Sprintf("%s/api/lists/%s/splash/%dx%d.png", apiBaseURL(r), listID, width, height),
+			"media": s.media(),
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(links)
+}
+
+// GetListSplash handles GET /api/lists/{listId}/splash/{size} - an iOS splash screen, e.g. 1170x2532.png
+// Only the sizes of known devices are rendered
+func GetListSplash(w http.ResponseWriter, r *http.Request) {
+	var width, height int
+	sizeStr := strings.TrimSuffix(r.PathValue("size"), ".png")
+	if _, err := fmt.Sscanf(sizeStr, "%dx%d", &width, &height); err != nil || !isSplashSize(width, height) {
+		http.Error(w, "Unknown splash screen size", http.StatusBadRequest)
+		return
+	}
+
+	src, err := loadIconSource(context.Background(), r.PathValue("listId"))
+	if err != nil {
+		http.Error(w, "List not found", http.StatusNotFound)
+		return
+	}
+
+	key := src.key(width, height, "splash")
+	serveIcon(w, r, key, "image/png", func() ([]byte, error) {
+		return encodePNG(renderIcon(key, splashEmojiScale))
+	})
+}
+
+// isSplashSize reports whether a size in device pixels belongs to one of the splashScreens
+func isSplashSize(width, height int) bool {
+	for _, s := range splashScreens {
+		if w, h := s.pixels(); w == width && h == height {
+			return true
+		}
+	}
+	return false
+}
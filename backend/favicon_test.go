@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image/png"
+	"testing"
+)
+
+func TestEncodeICO(t *testing.T) {
+	images := [][]byte{[]byte("sixteen"), []byte("thirty-two!"), []byte("big")}
+	sizes := []int{16, 32, 256}
+	ico, err := encodeICO(sizes, images)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var header [3]uint16
+	r := bytes.NewReader(ico)
+	binary.Read(r, binary.LittleEndian, &header)
+	if header != [3]uint16{0, 1, 3} {
+		t.Fatalf("ICONDIR = %v, want [0 1 3]", header)
+	}
+
+	wantOffset := 6 + 16*len(images)
+	for i, data := range images {
+		var entry struct {
+			Width, Height, Colors, Reserved uint8
+			Planes, BitCount                uint16
+			Size, Offset                    uint32
+		}
+		if err := binary.Read(r, binary.LittleEndian, &entry); err != nil {
+			t.Fatal(err)
+		}
+		wantDim := uint8(sizes[i])
+		if sizes[i] == 256 {
+			wantDim = 0
+		}
+		if entry.Width != wantDim || entry.Height != wantDim {
+			t.Errorf("entry %d: %dx%d, want %dx%d", i, entry.Width, entry.Height, wantDim, wantDim)
+		}
+		if entry.Planes != 1 || entry.BitCount != 32 {
+			t.Errorf("entry %d: planes %d, bit count %d, want 1 and 32", i, entry.Planes, entry.BitCount)
+		}
+		if int(entry.Size) != len(data) || int(entry.Offset) != wantOffset {
+			t.Errorf("entry %d: size %d at %d, want %d at %d", i, entry.Size, entry.Offset, len(data), wantOffset)
+		}
+		if got := ico[entry.Offset : entry.Offset+entry.Size]; !bytes.Equal(got, data) {
+			t.Errorf("entry %d: data %q, want %q", i, got, data)
+		}
+		wantOffset += len(data)
+	}
+	if len(ico) != wantOffset {
+		t.Errorf("file is %d bytes, want %d", len(ico), wantOffset)
+	}
+}
+
+func TestFaviconImages(t *testing.T) {
+	key := iconKey{Content: "🛒", HexColor: "4caf50", Variant: "favicon", Format: "png"}
+	for _, size := range faviconSizes {
+		key.Width, key.Height = size, size
+		data, err := encodePNG(renderIcon(key, iconEmojiScale["favicon"]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b := img.Bounds(); b.Dx() != size || b.Dy() != size {
+			t.Errorf("favicon image is %dx%d, want %dx%d", b.Dx(), b.Dy(), size, size)
+		}
+	}
+}
+
+func TestIsSplashSize(t *testing.T) {
+	tests := []struct {
+		width, height int
+		want          bool
+	}{
+		{750, 1334, true},
+		{1179, 2556, true},
+		{2048, 2732, true},
+		{1334, 750, false},
+		{1000, 1000, false},
+	}
+	for _, tt := range tests {
+		if got := isSplashSize(tt.width, tt.height); got != tt.want {
+			t.Errorf("isSplashSize(%d, %d) = %v, want %v", tt.width, tt.height, got, tt.want)
+		}
+	}
+}
@@ -3,37 +3,51 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"regexp"
 	"time"
+
+	"github.com/jackc/pgx/v5"
 )
 
 // Input validation limits
 const (
 	maxListNameLength = 15
 	maxItemNameLength = 100
-	maxHexColorLength = 6
 )
 
+// hexColorPattern is the only colour format accepted: six hex digits without "#"
+// Colours end up in SVG and CSS, so nothing else may get through
+var hexColorPattern = regexp.MustCompile(`^[0-9a-fA-F]{6}$`)
+
+// validHexColor reports whether s is a colour like "42b883"
+func validHexColor(s string) bool {
+	return hexColorPattern.MatchString(s)
+}
+
 // List represents a shopping list
 type List struct {
-	ID        string    `json:"id"`
+	ID        string    `json:"id,omitempty"` // left out where the viewer may only read the list
 	Name      string    `json:"name"`
 	Emoji     *string   `json:"emoji"`
 	HexColor  string    `json:"hex_color"`
+	IsPrivate bool      `json:"is_private"` // Share previews show only the item count
 	CreatedAt time.Time `json:"created_at"`
 }
 
 // Item represents a shopping list item
 // The `json:"..."` tags tell Go how to convert to/from JSON
 type Item struct {
-	ID          string    `json:"id"`
-	ListID      string    `json:"list_id"`
-	Name        string    `json:"name"`
-	Checked     bool      `json:"checked"`
-	SortOrder   float64   `json:"sort_order"`
-	IsSeparator bool      `json:"is_separator"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID        string    `json:"id"`
+	ListID    string    `json:"list_id,omitempty"`
+	Name      string    `json:"name"`
+	Checked   bool      `json:"checked"`
+	SortOrder float64   `json:"sort_order"` // order among its siblings (same section and parent)
+	SectionID *string   `json:"section_id"`
+	ParentID  *string   `json:"parent_id"` // set for sub-items
+	CreatedAt time.Time `json:"created_at"`
 }
 
 // GetItems handles GET /api/lists/{listId}/items - returns items for a specific list
@@ -44,38 +58,45 @@ func GetItems(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Query items for this list, ordered by sort_order then created_at
-	rows, err := DB.Query(context.Background(),
-		`SELECT id, list_id, name, checked, sort_order, is_separator, created_at
-		 FROM items WHERE list_id = $1
-		 ORDER BY sort_order ASC, created_at DESC`, listID)
+	items, err := fetchItems(context.Background(), listID)
 	if err != nil {
 		http.Error(w, "Failed to fetch items", http.StatusInternalServerError)
 		return
 	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(items)
+}
+
+// fetchItems returns a list's items without a section first, then by section and sort_order,
+// each item followed by its sub-items
+func fetchItems(ctx context.Context, listID string) ([]Item, error) {
+	rows, err := DB.Query(ctx,
+		`SELECT i.id, i.list_id, i.name, i.checked, i.sort_order, i.section_id::text, i.parent_id::text, i.created_at
+		 FROM items i `+itemTreeJoins+`
+		 WHERE i.list_id = $1
+		 ORDER BY `+itemTreeOrder, listID)
+	if err != nil {
+		return nil, err
+	}
 	defer rows.Close()
 
-	var items []Item
+	items := []Item{}
 	for rows.Next() {
 		var item Item
 		err := rows.Scan(&item.ID, &item.ListID, &item.Name, &item.Checked,
-			&item.SortOrder, &item.IsSeparator, &item.CreatedAt)
+			&item.SortOrder, &item.SectionID, &item.ParentID, &item.CreatedAt)
 		if err != nil {
-			http.Error(w, "Failed to scan item", http.StatusInternalServerError)
-			return
+			return nil, err
 		}
 		items = append(items, item)
 	}
-
-	if items == nil {
-		items = []Item{}
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(items)
+	return items, rows.Err()
 }
 
 // CreateItem handles POST /api/lists/{listId}/items - creates a new item
+// It's appended to the end of its section ("section_id", or the section bound to "category"; none by default)
+// or as a sub-item of "parent_id", or placed with "after_id" and/or "before_id" among that neighbour's siblings
 func CreateItem(w http.ResponseWriter, r *http.Request) {
 	listID := r.PathValue("listId")
 	if listID == "" {
@@ -84,15 +105,19 @@ func CreateItem(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var input struct {
-		Name        string `json:"name"`
-		IsSeparator bool   `json:"is_separator"`
+		Name      string `json:"name"`
+		SectionID string `json:"section_id"`
+		Category  string `json:"category"`
+		ParentID  string `json:"parent_id"`
+		AfterID   string `json:"after_id"`
+		BeforeID  string `json:"before_id"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
 		http.Error(w, "Invalid JSON", http.StatusBadRequest)
 		return
 	}
 
-	if input.Name == "" && !input.IsSeparator {
+	if input.Name == "" {
 		http.Error(w, "Name is required", http.StatusBadRequest)
 		return
 	}
@@ -101,34 +126,206 @@ func CreateItem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get max sort_order for this list to append at the end
-	var maxOrder float64
-	DB.QueryRow(context.Background(),
-		"SELECT COALESCE(MAX(sort_order), 0) FROM items WHERE list_id = $1",
-		listID).Scan(&maxOrder)
+	ctx := context.Background()
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		http.Error(w, "Failed to create item", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback(ctx)
 
-	var item Item
-	err := DB.QueryRow(context.Background(),
-		`INSERT INTO items (list_id, name, is_separator, sort_order)
-		 VALUES ($1, $2, $3, $4)
-		 RETURNING id, list_id, name, checked, sort_order, is_separator, created_at`,
-		listID, input.Name, input.IsSeparator, maxOrder+1,
-	).Scan(&item.ID, &item.ListID, &item.Name, &item.Checked,
-		&item.SortOrder, &item.IsSeparator, &item.CreatedAt)
+	// Lock the list so concurrent additions get distinct sort_orders and can't both add the same item
+	maxOrder, err := lockListForAppend(ctx, tx, listID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, "List not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to create item", http.StatusInternalServerError)
+		return
+	}
 
+	place := itemPlace{}
+	place.SectionID, err = resolveSection(ctx, tx, listID, input.SectionID, input.Category)
+	if errors.Is(err, errSectionNotFound) {
+		http.Error(w, "Section not found", http.StatusNotFound)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Failed to create item", http.StatusInternalServerError)
 		return
 	}
+	if input.ParentID != "" {
+		place, err = resolveParent(ctx, tx, listID, "", input.ParentID)
+		if errors.Is(err, errParentNotFound) {
+			http.Error(w, "Parent item not found", http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, errNestingTooDeep) {
+			http.Error(w, "Sub-items can't have sub-items of their own", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Failed to create item", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	// Append at the end, or at the requested position
+	sortOrder, dense := maxOrder+rankStep, false
+	if input.AfterID != "" || input.BeforeID != "" {
+		sortOrder, place, dense, err = rankFor(ctx, tx, listID, "", place, input.AfterID, input.BeforeID)
+		if errors.Is(err, errNeighbourNotFound) {
+			http.Error(w, "Neighbour item not found", http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, errNotAdjacent) {
+			http.Error(w, "List has changed, reload it", http.StatusConflict)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Failed to create item", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	// Adding an item that's already there (by normalized name, among the same parent's items) doesn't create
+	// a duplicate: a checked one is unchecked again, an unchecked one is returned as it is
+	synonyms, err := loadSynonyms(ctx, tx, listID)
+	if err != nil {
+		http.Error(w, "Failed to create item", http.StatusInternalServerError)
+		return
+	}
+	existing, err := findItemByKey(ctx, tx, listID, place.ParentID, itemKey(input.Name, synonyms), synonyms)
+	if err != nil {
+		http.Error(w, "Failed to create item", http.StatusInternalServerError)
+		return
+	}
+	if existing != nil {
+		if existing.Checked {
+			existing.Checked = false
+			_, err := tx.Exec(ctx,
+				"UPDATE items SET checked = false WHERE id = $1", existing.ID)
+			if err == nil {
+				err = checkItem(ctx, tx, *existing)
+			}
+			if err != nil {
+				http.Error(w, "Failed to update item", http.StatusInternalServerError)
+				return
+			}
+			if err := tx.Commit(ctx); err != nil {
+				http.Error(w, "Failed to update item", http.StatusInternalServerError)
+				return
+			}
+			go TrackItemAddition(listID, existing.Name)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(existing)
+		return
+	}
+
+	item, err := insertItem(ctx, tx, listID,
+		itemSeed{Name: input.Name, SectionID: place.SectionID, ParentID: place.ParentID}, sortOrder)
+	if err != nil {
+		http.Error(w, "Failed to create item", http.StatusInternalServerError)
+		return
+	}
+	// A new sub-item is unchecked, and so is its parent now
+	if err := syncParentChecked(ctx, tx, item.ParentID); err != nil {
+		http.Error(w, "Failed to create item", http.StatusInternalServerError)
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		http.Error(w, "Failed to create item", http.StatusInternalServerError)
+		return
+	}
+	if dense {
+		rebalanceInBackground(listID)
+	}
 
 	// Track item addition for recommendations (async, don't block response)
 	go TrackItemAddition(listID, input.Name)
+	go rememberListLanguage(listID, requestLanguage(r))
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(item)
 }
 
+// itemSeed holds the fields needed to create an item (from a request, a template or another list)
+// A separator seed (from a template or a copied list) starts a new section named like it
+type itemSeed struct {
+	Name        string  `json:"name"`
+	Checked     bool    `json:"checked"`
+	IsSeparator bool    `json:"is_separator"`
+	SectionID   *string `json:"section_id"`
+	ParentID    *string `json:"parent_id"`
+	Parent      *int    `json:"-"` // index of an earlier seed to nest under (copied lists)
+}
+
+// insertItem inserts a single item at the given sort_order
+func insertItem(ctx context.Context, q querier, listID string, seed itemSeed, sortOrder float64) (Item, error) {
+	var item Item
+	err := q.QueryRow(ctx,
+		`INSERT INTO items (list_id, name, checked, section_id, parent_id, sort_order)
+		 VALUES ($1, $2, $3, $4::uuid, $5::uuid, $6)
+		 RETURNING id, list_id, name, checked, sort_order, section_id::text, parent_id::text, created_at`,
+		listID, seed.Name, seed.Checked, seed.SectionID, seed.ParentID, sortOrder,
+	).Scan(&item.ID, &item.ListID, &item.Name, &item.Checked,
+		&item.SortOrder, &item.SectionID, &item.ParentID, &item.CreatedAt)
+	return item, err
+}
+
+// lockListForAppend locks a list row for the rest of the transaction and returns its highest sort_order
+// Returns pgx.ErrNoRows if the list doesn't exist
+func lockListForAppend(ctx context.Context, tx pgx.Tx, listID string) (float64, error) {
+	var maxOrder float64
+	err := tx.QueryRow(ctx,
+		`SELECT COALESCE((SELECT MAX(sort_order) FROM items WHERE list_id = l.id), 0)
+		 FROM lists l WHERE l.id = $1 FOR UPDATE`,
+		listID).Scan(&maxOrder)
+	return maxOrder, err
+}
+
+// appendItems adds items to the end of a list inside a transaction and records them in item_history
+// Separator seeds become new sections at the end of the list, holding the items that follow them
+func appendItems(ctx context.Context, tx pgx.Tx, listID string, seeds []itemSeed) ([]Item, error) {
+	maxOrder, err := lockListForAppend(ctx, tx, listID)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []Item{}
+	ids := make([]string, len(seeds))
+	var section *string
+	for i, seed := range seeds {
+		if seed.IsSeparator {
+			s, err := insertSection(ctx, tx, listID, sectionInput{Name: &seed.Name})
+			if err != nil {
+				return nil, err
+			}
+			section = &s.ID
+			continue
+		}
+		if seed.SectionID == nil {
+			seed.SectionID = section
+		}
+		if seed.Parent != nil && ids[*seed.Parent] != "" {
+			seed.ParentID = &ids[*seed.Parent]
+		}
+		item, err := insertItem(ctx, tx, listID, seed, maxOrder+float64(i+1))
+		if err != nil {
+			return nil, err
+		}
+		ids[i] = item.ID
+		if err := trackItemAddition(ctx, tx, listID, seed.Name); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
 // UpdateItem handles PATCH /api/lists/{listId}/items/{id} - updates an item
 func UpdateItem(w http.ResponseWriter, r *http.Request) {
 	listID := r.PathValue("listId")
@@ -138,11 +335,7 @@ func UpdateItem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var input struct {
-		Checked   *bool    `json:"checked"`
-		Name      *string  `json:"name"`
-		SortOrder *float64 `json:"sort_order"`
-	}
+	var input itemUpdate
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
 		http.Error(w, "Invalid JSON", http.StatusBadRequest)
 		return
@@ -153,7 +346,63 @@ func UpdateItem(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, fmt.Sprintf("Item name must be %d characters or less", maxItemNameLength), http.StatusBadRequest)
 		return
 	}
+	if input.empty() {
+		http.Error(w, "No fields to update", http.StatusBadRequest)
+		return
+	}
+
+	// Sub-items and parents change along with the item, so it's all one transaction
+	ctx := context.Background()
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		http.Error(w, "Failed to update item", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	item, err := updateItem(ctx, tx, listID, id, input)
+	switch {
+	case errors.Is(err, errSectionNotFound):
+		http.Error(w, "Section not found", http.StatusNotFound)
+		return
+	case errors.Is(err, errParentNotFound):
+		http.Error(w, "Parent item not found", http.StatusNotFound)
+		return
+	case errors.Is(err, errNestingTooDeep):
+		http.Error(w, "Sub-items can't have sub-items of their own", http.StatusBadRequest)
+		return
+	case err != nil:
+		http.Error(w, "Item not found", http.StatusNotFound)
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		http.Error(w, "Failed to update item", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item)
+}
+
+// itemUpdate holds the fields of an item to change; nil fields are left as they are
+// An empty section_id takes the item out of its section, an empty parent_id makes a sub-item a top-level item
+type itemUpdate struct {
+	Checked   *bool    `json:"checked"`
+	Name      *string  `json:"name"`
+	SortOrder *float64 `json:"sort_order"`
+	SectionID *string  `json:"section_id"`
+	ParentID  *string  `json:"parent_id"`
+}
+
+func (u itemUpdate) empty() bool {
+	return u.Checked == nil && u.Name == nil && u.SortOrder == nil && u.SectionID == nil && u.ParentID == nil
+}
 
+// updateItem applies an update to an item of the list, optionally inside a transaction
+// Sub-items follow their parent's checked state and section, and a parent is checked once all its sub-items are.
+// Returns pgx.ErrNoRows if the item doesn't exist or belongs to another list, errSectionNotFound if the
+// new section isn't one of the list's, errParentNotFound or errNestingTooDeep for an invalid parent
+func updateItem(ctx context.Context, q querier, listID, id string, input itemUpdate) (Item, error) {
 	// Build dynamic update query based on provided fields
 	args := []any{}
 	argNum := 1
@@ -175,9 +424,48 @@ func UpdateItem(w http.ResponseWriter, r *http.Request) {
 		argNum++
 	}
 
-	if len(updates) == 0 {
-		http.Error(w, "No fields to update", http.StatusBadRequest)
-		return
+	// A sub-item is always in its parent's section
+	var oldParentID *string
+	if input.ParentID != nil {
+		err := q.QueryRow(ctx,
+			"SELECT parent_id::text FROM items WHERE id::text = $1 AND list_id = $2",
+			id, listID).Scan(&oldParentID)
+		if err != nil {
+			return Item{}, err
+		}
+		place, err := resolveParent(ctx, q, listID, id, *input.ParentID)
+		if err != nil {
+			return Item{}, err
+		}
+		updates = append(updates, fmt.Sprintf("parent_id = $%d::uuid", argNum))
+		args = append(args, place.ParentID)
+		argNum++
+		if place.ParentID != nil {
+			updates = append(updates, fmt.Sprintf("section_id = $%d::uuid", argNum))
+			args = append(args, place.SectionID)
+			argNum++
+		}
+		// Without a position, the item goes to the end of its new siblings
+		if input.SortOrder == nil {
+			updates = append(updates, fmt.Sprintf(
+				"sort_order = (SELECT COALESCE(MAX(sort_order), 0) FROM items WHERE list_id = $%d) + $%d", argNum, argNum+1))
+			args = append(args, listID, rankStep)
+			argNum += 2
+		}
+	}
+	if input.SectionID != nil && (input.ParentID == nil || *input.ParentID == "") {
+		section, err := resolveSection(ctx, q, listID, *input.SectionID, "")
+		if err != nil {
+			return Item{}, err
+		}
+		// Sub-items that aren't taken out of their parent keep its section
+		if input.ParentID == nil {
+			updates = append(updates, fmt.Sprintf("section_id = CASE WHEN parent_id IS NULL THEN $%d::uuid ELSE section_id END", argNum))
+		} else {
+			updates = append(updates, fmt.Sprintf("section_id = $%d::uuid", argNum))
+		}
+		args = append(args, section)
+		argNum++
 	}
 
 	query := "UPDATE items SET " + updates[0]
@@ -186,24 +474,45 @@ func UpdateItem(w http.ResponseWriter, r *http.Request) {
 	}
 	// Verify item belongs to the specified list
 	query += fmt.Sprintf(" WHERE id = $%d AND list_id = $%d", argNum, argNum+1)
-	query += " RETURNING id, list_id, name, checked, sort_order, is_separator, created_at"
+	query += " RETURNING id, list_id, name, checked, sort_order, section_id::text, parent_id::text, created_at"
 	args = append(args, id, listID)
 
 	var item Item
-	err := DB.QueryRow(context.Background(), query, args...).Scan(
+	err := q.QueryRow(ctx, query, args...).Scan(
 		&item.ID, &item.ListID, &item.Name, &item.Checked,
-		&item.SortOrder, &item.IsSeparator, &item.CreatedAt)
-
+		&item.SortOrder, &item.SectionID, &item.ParentID, &item.CreatedAt)
 	if err != nil {
-		http.Error(w, "Item not found", http.StatusNotFound)
-		return
+		return item, err
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(item)
+	if input.Checked != nil {
+		if err := checkItem(ctx, q, item); err != nil {
+			return item, err
+		}
+	}
+	if input.SectionID != nil || input.ParentID != nil {
+		// Sub-items move into their parent's new section
+		_, err := q.Exec(ctx,
+			"UPDATE items SET section_id = $2::uuid WHERE parent_id::text = $1", item.ID, item.SectionID)
+		if err != nil {
+			return item, err
+		}
+	}
+	if input.ParentID != nil {
+		// Both the old and the new parent may be complete now
+		if err := syncParentChecked(ctx, q, oldParentID); err != nil {
+			return item, err
+		}
+		if err := syncParentChecked(ctx, q, item.ParentID); err != nil {
+			return item, err
+		}
+	}
+	return item, nil
 }
 
-// ReorderItems handles PUT /api/lists/{listId}/items/reorder - reorders items
+// ReorderItems handles PUT /api/lists/{listId}/items/reorder - sets the order of many items at once
+// All items are renumbered in a single statement, so a failure leaves the order untouched.
+// To move one item, MoveItemPosition only changes that item
 func ReorderItems(w http.ResponseWriter, r *http.Request) {
 	listID := r.PathValue("listId")
 	if listID == "" {
@@ -224,15 +533,24 @@ func ReorderItems(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Update sort_order for each item based on its position in the array
-	for i, itemID := range input.ItemIDs {
-		_, err := DB.Exec(context.Background(),
-			"UPDATE items SET sort_order = $1 WHERE id = $2 AND list_id = $3",
-			float64(i+1), itemID, listID)
-		if err != nil {
-			http.Error(w, "Failed to reorder items", http.StatusInternalServerError)
-			return
-		}
+	// sort_order becomes the position in the array among the item's siblings (1, 2, 3, ...),
+	// so the array can be the whole tree with sub-items after their parent; a repeated ID keeps its first position
+	_, err := DB.Exec(context.Background(),
+		`UPDATE items SET sort_order = o.position * $3
+		 FROM (
+			SELECT i.id, row_number() OVER (PARTITION BY i.parent_id ORDER BY u.position) AS position
+			FROM items i
+			JOIN (
+				SELECT id, MIN(ord) AS position FROM unnest($2::text[]) WITH ORDINALITY AS u(id, ord)
+				GROUP BY id
+			) u ON u.id = i.id::text
+			WHERE i.list_id = $1
+		 ) o
+		 WHERE items.id = o.id`,
+		listID, input.ItemIDs, rankStep)
+	if err != nil {
+		http.Error(w, "Failed to reorder items", http.StatusInternalServerError)
+		return
 	}
 
 	w.WriteHeader(http.StatusOK)
@@ -240,6 +558,7 @@ func ReorderItems(w http.ResponseWriter, r *http.Request) {
 }
 
 // DeleteItem handles DELETE /api/lists/{listId}/items/{id} - deletes an item
+// Its sub-items are deleted with it, unless ?sub_items=keep: then they take its place in the list
 func DeleteItem(w http.ResponseWriter, r *http.Request) {
 	listID := r.PathValue("listId")
 	id := r.PathValue("id")
@@ -247,19 +566,68 @@ func DeleteItem(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "List ID and Item ID are required", http.StatusBadRequest)
 		return
 	}
+	subItems := r.URL.Query().Get("sub_items")
+	if subItems != "" && subItems != "delete" && subItems != "keep" {
+		http.Error(w, "sub_items must be delete or keep", http.StatusBadRequest)
+		return
+	}
 
-	// Verify item belongs to the specified list before deleting
-	result, err := DB.Exec(context.Background(),
-		"DELETE FROM items WHERE id = $1 AND list_id = $2", id, listID)
+	ctx := context.Background()
+	tx, err := DB.Begin(ctx)
 	if err != nil {
 		http.Error(w, "Failed to delete item", http.StatusInternalServerError)
 		return
 	}
+	defer tx.Rollback(ctx)
 
-	if result.RowsAffected() == 0 {
+	// Lock the list while sub-items are given new ranks
+	if _, err := lockListForAppend(ctx, tx, listID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, "List not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to delete item", http.StatusInternalServerError)
+		return
+	}
+
+	dense := false
+	if subItems == "keep" {
+		dense, err = promoteSubItems(ctx, tx, listID, id)
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, "Item not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Failed to delete item", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	// Verify item belongs to the specified list before deleting
+	var parentID *string
+	err = tx.QueryRow(ctx,
+		"DELETE FROM items WHERE id = $1 AND list_id = $2 RETURNING parent_id::text", id, listID).Scan(&parentID)
+	if errors.Is(err, pgx.ErrNoRows) {
 		http.Error(w, "Item not found", http.StatusNotFound)
 		return
 	}
+	if err != nil {
+		http.Error(w, "Failed to delete item", http.StatusInternalServerError)
+		return
+	}
+
+	// Without an unchecked sub-item left, the parent is done
+	if err := syncParentChecked(ctx, tx, parentID); err != nil {
+		http.Error(w, "Failed to delete item", http.StatusInternalServerError)
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		http.Error(w, "Failed to delete item", http.StatusInternalServerError)
+		return
+	}
+	if dense {
+		rebalanceInBackground(listID)
+	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -269,7 +637,7 @@ func DeleteItem(w http.ResponseWriter, r *http.Request) {
 // GetLists handles GET /api/lists - returns all lists
 func GetLists(w http.ResponseWriter, r *http.Request) {
 	rows, err := DB.Query(context.Background(),
-		"SELECT id, name, emoji, hex_color, created_at FROM lists ORDER BY created_at ASC")
+		"SELECT id, name, emoji, hex_color, is_private, created_at FROM lists ORDER BY created_at ASC")
 	if err != nil {
 		http.Error(w, "Failed to fetch lists", http.StatusInternalServerError)
 		return
@@ -279,7 +647,7 @@ func GetLists(w http.ResponseWriter, r *http.Request) {
 	var lists []List
 	for rows.Next() {
 		var list List
-		err := rows.Scan(&list.ID, &list.Name, &list.Emoji, &list.HexColor, &list.CreatedAt)
+		err := rows.Scan(&list.ID, &list.Name, &list.Emoji, &list.HexColor, &list.IsPrivate, &list.CreatedAt)
 		if err != nil {
 			http.Error(w, "Failed to scan list", http.StatusInternalServerError)
 			return
@@ -305,8 +673,8 @@ func GetList(w http.ResponseWriter, r *http.Request) {
 
 	var list List
 	err := DB.QueryRow(context.Background(),
-		"SELECT id, name, emoji, hex_color, created_at FROM lists WHERE id = $1",
-		id).Scan(&list.ID, &list.Name, &list.Emoji, &list.HexColor, &list.CreatedAt)
+		"SELECT id, name, emoji, hex_color, is_private, created_at FROM lists WHERE id = $1",
+		id).Scan(&list.ID, &list.Name, &list.Emoji, &list.HexColor, &list.IsPrivate, &list.CreatedAt)
 
 	if err != nil {
 		http.Error(w, "List not found", http.StatusNotFound)
@@ -342,29 +710,35 @@ func CreateList(w http.ResponseWriter, r *http.Request) {
 	if input.HexColor == "" {
 		input.HexColor = "42b883"
 	}
-	if len(input.HexColor) > maxHexColorLength {
+	if !validHexColor(input.HexColor) {
 		http.Error(w, "Invalid hex color", http.StatusBadRequest)
 		return
 	}
 
-	var list List
-	err := DB.QueryRow(context.Background(),
-		`INSERT INTO lists (name, emoji, hex_color)
-		 VALUES ($1, $2, $3)
-		 RETURNING id, name, emoji, hex_color, created_at`,
-		input.Name, input.Emoji, input.HexColor,
-	).Scan(&list.ID, &list.Name, &list.Emoji, &list.HexColor, &list.CreatedAt)
-
+	list, err := insertList(context.Background(), DB, List{Name: input.Name, Emoji: input.Emoji, HexColor: input.HexColor})
 	if err != nil {
 		http.Error(w, "Failed to create list", http.StatusInternalServerError)
 		return
 	}
+	go rememberListLanguage(list.ID, requestLanguage(r))
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(list)
 }
 
+// insertList creates a list row with already validated fields
+func insertList(ctx context.Context, q querier, input List) (List, error) {
+	var list List
+	err := q.QueryRow(ctx,
+		`INSERT INTO lists (name, emoji, hex_color, is_private)
+		 VALUES ($1, $2, $3, $4)
+		 RETURNING id, name, emoji, hex_color, is_private, created_at`,
+		input.Name, input.Emoji, input.HexColor, input.IsPrivate,
+	).Scan(&list.ID, &list.Name, &list.Emoji, &list.HexColor, &list.IsPrivate, &list.CreatedAt)
+	return list, err
+}
+
 // UpdateList handles PATCH /api/lists/{id} - updates a list
 func UpdateList(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
@@ -374,9 +748,10 @@ func UpdateList(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var input struct {
-		Name     *string `json:"name"`
-		Emoji    *string `json:"emoji"`
-		HexColor *string `json:"hex_color"`
+		Name      *string `json:"name"`
+		Emoji     *string `json:"emoji"`
+		HexColor  *string `json:"hex_color"`
+		IsPrivate *bool   `json:"is_private"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
 		http.Error(w, "Invalid JSON", http.StatusBadRequest)
@@ -388,7 +763,7 @@ func UpdateList(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, fmt.Sprintf("Name must be %d characters or less", maxListNameLength), http.StatusBadRequest)
 		return
 	}
-	if input.HexColor != nil && len(*input.HexColor) > maxHexColorLength {
+	if input.HexColor != nil && !validHexColor(*input.HexColor) {
 		http.Error(w, "Invalid hex color", http.StatusBadRequest)
 		return
 	}
@@ -396,8 +771,8 @@ func UpdateList(w http.ResponseWriter, r *http.Request) {
 	// Fetch current list first
 	var list List
 	err := DB.QueryRow(context.Background(),
-		"SELECT id, name, emoji, hex_color, created_at FROM lists WHERE id = $1",
-		id).Scan(&list.ID, &list.Name, &list.Emoji, &list.HexColor, &list.CreatedAt)
+		"SELECT id, name, emoji, hex_color, is_private, created_at FROM lists WHERE id = $1",
+		id).Scan(&list.ID, &list.Name, &list.Emoji, &list.HexColor, &list.IsPrivate, &list.CreatedAt)
 	if err != nil {
 		http.Error(w, "List not found", http.StatusNotFound)
 		return
@@ -413,19 +788,25 @@ func UpdateList(w http.ResponseWriter, r *http.Request) {
 	if input.HexColor != nil {
 		list.HexColor = *input.HexColor
 	}
+	if input.IsPrivate != nil {
+		list.IsPrivate = *input.IsPrivate
+	}
 
 	// Save changes
 	err = DB.QueryRow(context.Background(),
-		`UPDATE lists SET name = $1, emoji = $2, hex_color = $3 WHERE id = $4
-		 RETURNING id, name, emoji, hex_color, created_at`,
-		list.Name, list.Emoji, list.HexColor, id,
-	).Scan(&list.ID, &list.Name, &list.Emoji, &list.HexColor, &list.CreatedAt)
+		`UPDATE lists SET name = $1, emoji = $2, hex_color = $3, is_private = $4 WHERE id = $5
+		 RETURNING id, name, emoji, hex_color, is_private, created_at`,
+		list.Name, list.Emoji, list.HexColor, list.IsPrivate, id,
+	).Scan(&list.ID, &list.Name, &list.Emoji, &list.HexColor, &list.IsPrivate, &list.CreatedAt)
 
 	if err != nil {
 		http.Error(w, "Failed to update list", http.StatusInternalServerError)
 		return
 	}
 
+	// Icons are drawn from the emoji, colour and (for monograms) name
+	invalidateListIcon(id)
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(list)
 }
@@ -438,6 +819,22 @@ func DeleteList(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Claimed lists can only be deleted by their owner
+	var ownerID *string
+	err := DB.QueryRow(context.Background(),
+		"SELECT owner_id FROM lists WHERE id = $1", id).Scan(&ownerID)
+	if err != nil {
+		http.Error(w, "List not found", http.StatusNotFound)
+		return
+	}
+	if ownerID != nil {
+		user, err := currentUser(r)
+		if err != nil || user.ID != *ownerID {
+			http.Error(w, "Only the owner can delete this list", http.StatusForbidden)
+			return
+		}
+	}
+
 	result, err := DB.Exec(context.Background(),
 		"DELETE FROM lists WHERE id = $1", id)
 	if err != nil {
@@ -449,6 +846,7 @@ func DeleteList(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "List not found", http.StatusNotFound)
 		return
 	}
+	invalidateListIcon(id)
 
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -457,22 +855,29 @@ func DeleteList(w http.ResponseWriter, r *http.Request) {
 
 // ItemHistory represents an item's addition history for recommendations
 type ItemHistory struct {
-	ID              string    `json:"id"`
-	ListID          string    `json:"list_id"`
-	ItemName        string    `json:"item_name"`
-	AddedCount      int       `json:"added_count"`
-	LastAddedAt     time.Time `json:"last_added_at"`
-	AvgDaysBetween  float64   `json:"avg_days_between"`
-	Dismissed       bool      `json:"dismissed"`
+	ID               string    `json:"id"`
+	ListID           string    `json:"list_id"`
+	ItemName         string    `json:"item_name"`
+	AddedCount       int       `json:"added_count"`
+	LastAddedAt      time.Time `json:"last_added_at"`
+	NameKey          string    `json:"-"`
+	AvgDaysBetween   *float64  `json:"avg_days_between"`
+	IntervalEWMA     *float64  `json:"interval_ewma"`
+	IntervalVariance *float64  `json:"interval_variance"`
+	Dismissed        bool      `json:"dismissed"`
 }
 
 // Recommendation represents a suggested item
+// Confidence (0-1) says how regular the item's purchase interval is, Reason explains the suggestion
 type Recommendation struct {
-	Name    string  `json:"name"`
-	Urgency float64 `json:"urgency"`
+	Name       string  `json:"name"`
+	Urgency    float64 `json:"urgency"`
+	Confidence float64 `json:"confidence"`
+	Reason     string  `json:"reason"`
 }
 
 // GetRecommendations returns item suggestions based on addition history
+// The scoring itself is done by a pluggable Recommender (see recommend.go)
 func GetRecommendations(w http.ResponseWriter, r *http.Request) {
 	listID := r.PathValue("listId")
 	if listID == "" {
@@ -480,50 +885,40 @@ func GetRecommendations(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Check if item_history table exists by querying it
-	// If it doesn't exist, return empty array
-	rows, err := DB.Query(context.Background(),
-		`SELECT item_name,
-			CASE
-				WHEN avg_days_between > 0 THEN
-					EXTRACT(EPOCH FROM (NOW() - last_added_at)) / 86400 / avg_days_between
-				ELSE 0.5
-			END as urgency
-		FROM item_history
-		WHERE list_id = $1
-			AND dismissed = false
-			AND added_count >= 2
-			AND item_name NOT IN (SELECT name FROM items WHERE list_id = $1)
-		ORDER BY urgency DESC
-		LIMIT 10`, listID)
+	// Pick the strategy (?strategy= lets clients compare them)
+	recommender := defaultRecommender()
+	if name := r.URL.Query().Get("strategy"); name != "" {
+		var ok bool
+		if recommender, ok = recommenders[name]; !ok {
+			http.Error(w, "Unknown recommendation strategy", http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Weekdays and months are counted in the client's time zone (?tz=Europe/Berlin)
+	loc, err := requestLocation(r.URL.Query().Get("tz"))
 	if err != nil {
-		// Table might not exist - return empty recommendations
-		w.Header().Set("Content-Type", "application/json")
-		w.Write([]byte("[]"))
+		http.Error(w, "Unknown time zone", http.StatusBadRequest)
 		return
 	}
-	defer rows.Close()
 
-	var recs []Recommendation
-	for rows.Next() {
-		var rec Recommendation
-		if err := rows.Scan(&rec.Name, &rec.Urgency); err != nil {
-			continue
-		}
-		if rec.Urgency >= 0.5 { // Only suggest items with decent urgency
-			recs = append(recs, rec)
-		}
+	// If the item_history table doesn't exist, return empty array
+	in, err := loadRecommendationInput(context.Background(), listID, requestLanguage(r), loc)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("[]"))
+		return
 	}
 
-	if recs == nil {
-		recs = []Recommendation{}
-	}
+	// New lists get popular items from other lists until they have history of their own
+	recs := topRecommendations(coldStartRecommender{recommender}.Recommend(in), maxRecommendations)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(recs)
 }
 
-// DismissRecommendation dismisses a recommendation
+// DismissRecommendation dismisses a recommendation until the item is added again or the dismissal is undone
+// Items without history of their own (popular suggestions) get a record with no additions to hold the dismissal
 func DismissRecommendation(w http.ResponseWriter, r *http.Request) {
 	listID := r.PathValue("listId")
 	name := r.PathValue("name")
@@ -532,10 +927,17 @@ func DismissRecommendation(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, err := DB.Exec(context.Background(),
-		`UPDATE item_history SET dismissed = true
-		WHERE list_id = $1 AND item_name = $2`,
-		listID, name)
+	itemName, key, err := historyName(context.Background(), DB, listID, name)
+	if err != nil {
+		http.Error(w, "Failed to dismiss recommendation", http.StatusInternalServerError)
+		return
+	}
+	_, err = DB.Exec(context.Background(),
+		`INSERT INTO item_history (list_id, item_name, name_key, added_count, last_added_at,
+			avg_days_between, dismissed, dismissed_at)
+		VALUES ($1, $2, $3, 0, NOW(), NULL, true, NOW())
+		ON CONFLICT (list_id, name_key) DO UPDATE SET dismissed = true, dismissed_at = NOW()`,
+		listID, itemName, key)
 	if err != nil {
 		http.Error(w, "Failed to dismiss recommendation", http.StatusInternalServerError)
 		return
@@ -547,26 +949,102 @@ func DismissRecommendation(w http.ResponseWriter, r *http.Request) {
 
 // TrackItemAddition updates item history when an item is added
 func TrackItemAddition(listID, itemName string) {
-	// Try to update existing record
-	result, err := DB.Exec(context.Background(),
-		`UPDATE item_history
-		SET added_count = added_count + 1,
+	trackItemAddition(context.Background(), DB, listID, itemName)
+}
+
+// trackItemAddition records an addition in item history, optionally inside a transaction
+// Spellings of the same item ("Milk", "milks", synonyms) share one record
+func trackItemAddition(ctx context.Context, q querier, listID, itemName string) error {
+	itemName, key, err := historyName(ctx, q, listID, itemName)
+	if err != nil {
+		return err
+	}
+
+	// Co-occurrence looks at what else was added recently, so it runs before last_added_at changes
+	if err := recordCooccurrence(ctx, q, listID, itemName); err != nil {
+		return err
+	}
+
+	// Update the interval model of an existing record (see intervals.go for the maths)
+	// Re-adds within a few minutes of the last one are ignored entirely. A record without additions
+	// only holds a dismissal or snooze (see DismissRecommendation), so this is its first addition
+	result, err := q.Exec(ctx,
+		`UPDATE item_history h
+		SET added_count = h.added_count + 1,
 			avg_days_between = CASE
-				WHEN added_count > 0 THEN
-					(avg_days_between * added_count + EXTRACT(EPOCH FROM (NOW() - last_added_at)) / 86400) / (added_count + 1)
-				ELSE 7
+				WHEN h.added_count = 0 THEN NULL
+				WHEN h.avg_days_between IS NULL THEN x.days
+				ELSE (h.avg_days_between * (h.added_count - 1) + x.days) / h.added_count
+			END,
+			interval_ewma = CASE
+				WHEN h.added_count = 0 THEN NULL
+				WHEN h.interval_ewma IS NULL THEN x.days
+				ELSE h.interval_ewma + $3 * (x.days - h.interval_ewma)
+			END,
+			interval_variance = CASE
+				WHEN h.added_count = 0 THEN NULL
+				WHEN h.interval_ewma IS NULL THEN 0
+				ELSE (1 - $3) * (COALESCE(h.interval_variance, 0) + $3 * (x.days - h.interval_ewma) ^ 2)
 			END,
 			last_added_at = NOW(),
-			dismissed = false
-		WHERE list_id = $1 AND item_name = $2`,
+			dismissed = false,
+			dismissed_at = NULL,
+			snoozed_until = NULL
+		FROM (
+			SELECT id, EXTRACT(EPOCH FROM (NOW() - last_added_at)) / 86400 AS days
+			FROM item_history WHERE list_id = $1 AND item_name = $2
+		) x
+		WHERE h.id = x.id AND (h.added_count = 0 OR h.last_added_at < NOW() - make_interval(mins => $4))`,
+		listID, itemName, intervalAlpha, readdDebounceMinutes)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		// Insert new record (does nothing if the row exists but was just added,
+		// or if a concurrent addition of another spelling just created it)
+		result, err = q.Exec(ctx,
+			`INSERT INTO item_history (list_id, item_name, name_key, added_count, last_added_at, dismissed)
+			VALUES ($1, $2, $3, 1, NOW(), false)
+			ON CONFLICT (list_id, name_key) DO NOTHING`,
+			listID, itemName, key)
+		if err != nil || result.RowsAffected() == 0 {
+			return err
+		}
+	}
+
+	// Keep the full timeline for offline evaluation and richer models
+	_, err = q.Exec(ctx,
+		"INSERT INTO item_additions (list_id, item_name) VALUES ($1, $2)",
 		listID, itemName)
+	return err
+}
 
-	if err != nil || result.RowsAffected() == 0 {
-		// Insert new record
-		DB.Exec(context.Background(),
-			`INSERT INTO item_history (list_id, item_name, added_count, last_added_at, avg_days_between, dismissed)
-			VALUES ($1, $2, 1, NOW(), 7, false)
-			ON CONFLICT DO NOTHING`,
-			listID, itemName)
+// untrackItemAddition reverts one addition, e.g. when an item is moved to another list
+// The row is removed once no additions are left
+func untrackItemAddition(ctx context.Context, q querier, listID, itemName string) error {
+	itemName, _, err := historyName(ctx, q, listID, itemName)
+	if err != nil {
+		return err
+	}
+	_, err = q.Exec(ctx,
+		"DELETE FROM item_history WHERE list_id = $1 AND item_name = $2 AND added_count <= 1",
+		listID, itemName)
+	if err != nil {
+		return err
 	}
+	_, err = q.Exec(ctx,
+		`UPDATE item_history SET added_count = added_count - 1
+		WHERE list_id = $1 AND item_name = $2 AND added_count > 1`,
+		listID, itemName)
+	if err != nil {
+		return err
+	}
+	_, err = q.Exec(ctx,
+		`DELETE FROM item_additions WHERE id = (
+			SELECT id FROM item_additions WHERE list_id = $1 AND item_name = $2
+			ORDER BY added_at DESC LIMIT 1
+		)`,
+		listID, itemName)
+	return err
 }
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestValidHexColor(t *testing.T) {
+	tests := []struct {
+		s    string
+		want bool
+	}{
+		{"42b883", true},
+		{"42B883", true},
+		{"000000", true},
+		{"#42b883", false},
+		{"42b88", false},
+		{"42b8833", false},
+		{"42b88g", false},
+		{"", false},
+		{`0"/><script>`, false},
+		{"42b883\n", false},
+	}
+	for _, tt := range tests {
+		if got := validHexColor(tt.s); got != tt.want {
+			t.Errorf("validHexColor(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestCreateItemRejectsBeforeWriting(t *testing.T) {
+	tests := []struct {
+		name, listID, body, want string
+	}{
+		{"no list", "", `{"name": "Milk"}`, "List ID is required"},
+		{"invalid JSON", "abc", `{"name":`, "Invalid JSON"},
+		{"no name", "abc", `{"after_id": "1"}`, "Name is required"},
+		{"long name", "abc", `{"name": "` + strings.Repeat("x", maxItemNameLength+1) + `"}`,
+			fmt.Sprintf("Item name must be %d characters or less", maxItemNameLength)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/api/lists/"+tt.listID+"/items", strings.NewReader(tt.body))
+			r.SetPathValue("listId", tt.listID)
+			w := httptest.NewRecorder()
+			CreateItem(w, r)
+			if w.Code != http.StatusBadRequest || strings.TrimSpace(w.Body.String()) != tt.want {
+				t.Errorf("got %d %q, want 400 %q", w.Code, strings.TrimSpace(w.Body.String()), tt.want)
+			}
+		})
+	}
+}
+
+func TestDeleteItemRejectsBeforeWriting(t *testing.T) {
+	tests := []struct {
+		name, listID, id, query, want string
+	}{
+		{"no list", "", "1", "", "List ID and Item ID are required"},
+		{"no item", "abc", "", "", "List ID and Item ID are required"},
+		{"unknown sub_items", "abc", "1", "?sub_items=move", "sub_items must be delete or keep"},
+		{"sub_items in another case", "abc", "1", "?sub_items=KEEP", "sub_items must be delete or keep"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("DELETE", "/api/lists/"+tt.listID+"/items/"+tt.id+tt.query, nil)
+			r.SetPathValue("listId", tt.listID)
+			r.SetPathValue("id", tt.id)
+			w := httptest.NewRecorder()
+			DeleteItem(w, r)
+			if w.Code != http.StatusBadRequest || strings.TrimSpace(w.Body.String()) != tt.want {
+				t.Errorf("got %d %q, want 400 %q", w.Code, strings.TrimSpace(w.Body.String()), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Icon cache settings (icons, favicons and splash screens)
+const (
+	iconCacheMaxBytes   = 32 << 20 // rendered icons kept in memory
+	iconSourceCacheSize = 1024     // lists whose emoji/colour are kept in memory
+	// How long a replica trusts its cached emoji/colour; UpdateList on another replica
+	// only clears that replica's cache, so this bounds how long others serve the old icon
+	iconSourceTTL = 30 * time.Second
+	// Bump when the rendering changes, so clients and the disk cache don't keep old icons
+	iconRenderVersion = 2
+)
+
+// lruCache is a size-bounded least-recently-used cache, safe for concurrent use
+type lruCache[K comparable, V any] struct {
+	mu      sync.Mutex
+	maxCost int
+	cost    int
+	order   *list.List // front = most recently used
+	items   map[K]*list.Element
+}
+
+type lruEntry[K comparable, V any] struct {
+	key   K
+	value V
+	cost  int
+}
+
+func newLRUCache[K comparable, V any](maxCost int) *lruCache[K, V] {
+	return &lruCache[K, V]{maxCost: maxCost, order: list.New(), items: map[K]*list.Element{}}
+}
+
+// Get returns a cached value and marks it as recently used
+func (c *lruCache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.order.MoveToFront(el)
+		return el.Value.(*lruEntry[K, V]).value, true
+	}
+	var zero V
+	return zero, false
+}
+
+// Add stores a value, evicting the least recently used entries until the total cost fits
+func (c *lruCache[K, V]) Add(key K, value V, cost int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.removeElement(el)
+	}
+	if cost > c.maxCost {
+		return
+	}
+	c.items[key] = c.order.PushFront(&lruEntry[K, V]{key: key, value: value, cost: cost})
+	c.cost += cost
+	for c.cost > c.maxCost {
+		c.removeElement(c.order.Back())
+	}
+}
+
+// Remove drops a key from the cache
+func (c *lruCache[K, V]) Remove(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.removeElement(el)
+	}
+}
+
+func (c *lruCache[K, V]) removeElement(el *list.Element) {
+	entry := el.Value.(*lruEntry[K, V])
+	c.order.Remove(el)
+	delete(c.items, entry.key)
+	c.cost -= entry.cost
+}
+
+// iconSource is what a list's icons are drawn from
+type iconSource struct {
+	Name     string
+	Emoji    string
+	HexColor string
+	loadedAt time.Time
+}
+
+// iconKey identifies a rendered image; Content is the emoji, or "monogram:XY" when there's no emoji asset
+// Format is "png", "svg" or "ico"; Variant is "any", "maskable", "favicon", "splash" or "preview",
+// or the shape ("rounded", "circle") for SVG. Text is the name and items on a preview card
+type iconKey struct {
+	Content  string
+	HexColor string
+	Width    int
+	Height   int
+	Format   string
+	Variant  string
+	Text     string
+}
+
+var (
+	iconSources = newLRUCache[string, iconSource](iconSourceCacheSize)
+	iconCache   = newLRUCache[iconKey, []byte](iconCacheMaxBytes)
+)
+
+// loadIconSource returns a list's emoji, colour and name, from memory if it was loaded recently
+func loadIconSource(ctx context.Context, listID string) (iconSource, error) {
+	if src, ok := iconSources.Get(listID); ok && time.Since(src.loadedAt) < iconSourceTTL {
+		return src, nil
+	}
+
+	var src iconSource
+	var emoji *string
+	err := DB.QueryRow(ctx,
+		"SELECT name, emoji, hex_color FROM lists WHERE id = $1",
+		listID).Scan(&src.Name, &emoji, &src.HexColor)
+	if err != nil {
+		return src, err
+	}
+	if emoji != nil {
+		src.Emoji = *emoji
+	}
+	if !validHexColor(src.HexColor) {
+		src.HexColor = "333333" // Default gray
+	}
+
+	src.loadedAt = time.Now()
+	iconSources.Add(listID, src, 1)
+	return src, nil
+}
+
+// invalidateListIcon forgets a list's cached emoji and colour, e.g. after UpdateList
+// Only this replica's cache is cleared; others pick up the change within iconSourceTTL
+// Rendered icons are keyed by content, so they don't have to be dropped
+func invalidateListIcon(listID string) {
+	iconSources.Remove(listID)
+}
+
+// key returns the cache key for one rendering of the source
+func (src iconSource) key(width, height int, variant string) iconKey {
+	content := src.Emoji
+	if _, ok := emojiAsset(content); content == "" || !ok {
+		content = "monogram:" + monogram(src.Name)
+	}
+	return iconKey{Content: content, HexColor: src.HexColor, Width: width, Height: height, Format: "png", Variant: variant}
+}
+
+// etag is a strong ETag: the same key always renders to the same bytes
+func (k iconKey) etag() string {
+	sum := sha256.Sum256(fmt.Appendf(nil, "%d|%s|%s|%dx%d|%s|%s|%s",
+		iconRenderVersion, k.Content, k.HexColor, k.Width, k.Height, k.Format, k.Variant, k.Text))
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// cachedIcon returns a rendered icon from memory, the disk cache (ICON_CACHE_DIR) or render
+func cachedIcon(key iconKey, render func() ([]byte, error)) ([]byte, error) {
+	if data, ok := iconCache.Get(key); ok {
+		return data, nil
+	}
+
+	diskPath := ""
+	if dir := os.Getenv("ICON_CACHE_DIR"); dir != "" {
+		diskPath = filepath.Join(dir, strings.Trim(key.etag(), `"`))
+		if data, err := os.ReadFile(diskPath); err == nil {
+			iconCache.Add(key, data, len(data))
+			return data, nil
+		}
+	}
+
+	data, err := render()
+	if err != nil {
+		return nil, err
+	}
+	iconCache.Add(key, data, len(data))
+
+	if diskPath != "" {
+		if err := writeFileAtomic(diskPath, data); err != nil {
+			log.Printf("Icon cache: %v", err)
+		}
+	}
+	return data, nil
+}
+
+// writeFileAtomic writes through a temporary file, so readers never see a partial icon
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".icon-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// etagMatches reports whether an If-None-Match header matches etag (weak comparison, as for GET)
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// serveIcon writes a cached icon with its ETag, or 304 Not Modified if the client already has it
+func serveIcon(w http.ResponseWriter, r *http.Request, key iconKey, contentType string, render func() ([]byte, error)) {
+	etag := key.etag()
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "public, max-age=3600") // Revalidate hourly, the emoji may change
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	data, err := cachedIcon(key, render)
+	if err != nil {
+		http.Error(w, "Failed to render icon", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Write(data)
+}
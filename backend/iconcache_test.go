@@ -0,0 +1,116 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLRUCache(t *testing.T) {
+	c := newLRUCache[string, int](10)
+	c.Add("a", 1, 4)
+	c.Add("b", 2, 4)
+	c.Get("a") // b is now the least recently used
+	c.Add("c", 3, 4)
+
+	tests := []struct {
+		key   string
+		want  int
+		found bool
+	}{
+		{"a", 1, true},
+		{"b", 0, false},
+		{"c", 3, true},
+	}
+	for _, tt := range tests {
+		if got, found := c.Get(tt.key); got != tt.want || found != tt.found {
+			t.Errorf("Get(%q) = %d, %t, want %d, %t", tt.key, got, found, tt.want, tt.found)
+		}
+	}
+	if c.cost != 8 {
+		t.Errorf("cost = %d, want 8", c.cost)
+	}
+
+	c.Add("a", 10, 2) // replacing a value updates its cost
+	if got, _ := c.Get("a"); got != 10 || c.cost != 6 {
+		t.Errorf("after replacing: value %d, cost %d, want 10 and 6", got, c.cost)
+	}
+	c.Add("huge", 0, 11) // never fits, and doesn't evict anything
+	if _, found := c.Get("huge"); found || c.cost != 6 {
+		t.Errorf("oversized entry: found %t, cost %d", found, c.cost)
+	}
+	c.Remove("c")
+	c.Remove("missing")
+	if _, found := c.Get("c"); found || c.cost != 2 || c.order.Len() != 1 {
+		t.Errorf("after Remove: found %t, cost %d, %d entries", found, c.cost, c.order.Len())
+	}
+}
+
+func TestEtagMatches(t *testing.T) {
+	etag := `"abc"`
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{"", false},
+		{`"abc"`, true},
+		{`W/"abc"`, true},
+		{`"xyz", "abc"`, true},
+		{`"xyz"`, false},
+		{"*", true},
+		{`abc`, false},
+	}
+	for _, tt := range tests {
+		if got := etagMatches(tt.header, etag); got != tt.want {
+			t.Errorf("etagMatches(%q) = %t, want %t", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestIconKeyEtag(t *testing.T) {
+	key := iconKey{Content: "🛒", HexColor: "42b883", Width: 192, Height: 192, Format: "png", Variant: "any"}
+	if key.etag() != key.etag() {
+		t.Error("etag isn't stable")
+	}
+	changed := key
+	changed.Variant = "maskable"
+	if key.etag() == changed.etag() {
+		t.Error("different variants have the same etag")
+	}
+	if e := key.etag(); !strings.HasPrefix(e, `"`) || !strings.HasSuffix(e, `"`) || len(e) != 34 {
+		t.Errorf("etag %s isn't a quoted 32-digit hash", e)
+	}
+}
+
+func TestCachedIconDisk(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("ICON_CACHE_DIR", dir)
+	key := iconKey{Content: "disk-cache-test", Width: 1, Height: 1, Format: "png"}
+
+	renders := 0
+	render := func() ([]byte, error) {
+		renders++
+		return []byte("icon"), nil
+	}
+	if data, err := cachedIcon(key, render); err != nil || string(data) != "icon" {
+		t.Fatalf("cachedIcon = %q, %v", data, err)
+	}
+	stored, err := os.ReadFile(filepath.Join(dir, strings.Trim(key.etag(), `"`)))
+	if err != nil || string(stored) != "icon" {
+		t.Fatalf("disk cache holds %q, %v", stored, err)
+	}
+
+	// Another replica (or a restart) finds it on disk instead of rendering
+	iconCache.Remove(key)
+	if data, err := cachedIcon(key, render); err != nil || string(data) != "icon" || renders != 1 {
+		t.Errorf("cachedIcon = %q, %v after %d renders, want 1", data, err, renders)
+	}
+
+	failing := key
+	failing.Content = "disk-cache-test-failing"
+	if _, err := cachedIcon(failing, func() ([]byte, error) { return nil, errors.New("boom") }); err == nil {
+		t.Error("render error was swallowed")
+	}
+}
@@ -4,23 +4,35 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"encoding/xml"
 	"fmt"
 	"image"
 	"image/color"
 	"image/draw"
 	"image/png"
-	"io"
 	"net/http"
-	"net/url"
 	"os"
 	"strconv"
 	"strings"
-
-	xdraw "golang.org/x/image/draw"
 )
 
-// GetListIcon generates a PNG icon with the list's emoji on its color background
-// GET /api/lists/{listId}/icon/{size}.png
+// Share of the icon covered by the emoji, per variant
+// Maskable icons may be cropped to a circle of 80% of their size (the safe zone),
+// so their emoji has to fit inside it: 0.5 * sqrt(2) < 0.8
+var iconEmojiScale = map[string]float64{
+	"any":      0.7,
+	"maskable": 0.5,
+	"favicon":  0.8,
+	"rounded":  0.7,
+	"circle":   0.6, // 0.6 * sqrt(2) < 1, so the corners stay inside the circle
+}
+
+// Corner radius of the "rounded" SVG shape, relative to the icon size
+const iconCornerRadius = 0.2
+
+// GetListIcon generates an icon with the list's emoji on its color background
+// GET /api/lists/{listId}/icon/{size}.png or {size}-maskable.png - rendered icons are cached, see iconcache.go
+// GET /api/lists/{listId}/icon/{size}.svg?shape=rounded|circle - vector icon
 func GetListIcon(w http.ResponseWriter, r *http.Request) {
 	listID := r.PathValue("listId")
 	sizeStr := r.PathValue("size")
@@ -30,59 +42,119 @@ func GetListIcon(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Parse size from path (e.g., "192.png" -> 192)
-	sizeStr = strings.TrimSuffix(sizeStr, ".png")
+	// Parse size and variant from path (e.g., "192.png" -> 192, "512-maskable.png" -> 512 maskable)
+	format := "png"
+	variant := "any"
+	if trimmed, ok := strings.CutSuffix(sizeStr, ".svg"); ok {
+		sizeStr, format = trimmed, "svg"
+		variant = r.URL.Query().Get("shape")
+		if variant == "" {
+			variant = "rounded"
+		}
+		if variant != "rounded" && variant != "circle" {
+			http.Error(w, "Invalid shape (rounded or circle)", http.StatusBadRequest)
+			return
+		}
+	} else {
+		sizeStr = strings.TrimSuffix(sizeStr, ".png")
+		if trimmed, ok := strings.CutSuffix(sizeStr, "-maskable"); ok {
+			sizeStr, variant = trimmed, "maskable"
+		}
+	}
 	size, err := strconv.Atoi(sizeStr)
 	if err != nil || size < 16 || size > 1024 {
 		http.Error(w, "Invalid size (16-1024)", http.StatusBadRequest)
 		return
 	}
 
-	// Fetch list from database
-	var list List
-	err = DB.QueryRow(context.Background(),
-		"SELECT id, name, emoji, hex_color, created_at FROM lists WHERE id = $1",
-		listID).Scan(&list.ID, &list.Name, &list.Emoji, &list.HexColor, &list.CreatedAt)
+	src, err := loadIconSource(context.Background(), listID)
 	if err != nil {
 		http.Error(w, "List not found", http.StatusNotFound)
 		return
 	}
 
-	// Parse hex color
-	hexColor := list.HexColor
-	if len(hexColor) != 6 {
-		hexColor = "333333" // Default gray
+	key := src.key(size, size, variant)
+	key.Format = format
+	if format == "svg" {
+		serveIcon(w, r, key, "image/svg+xml", func() ([]byte, error) {
+			return renderIconSVG(key, iconEmojiScale[variant]), nil
+		})
+		return
 	}
-	bgColor := parseHexColor(hexColor)
+	serveIcon(w, r, key, "image/png", func() ([]byte, error) {
+		return encodePNG(renderIcon(key, iconEmojiScale[variant]))
+	})
+}
+
+// renderIcon draws the emoji or monogram centered on the list's colour
+// emojiScale is the emoji's size relative to the shorter side of the image
+func renderIcon(key iconKey, emojiScale float64) *image.RGBA {
+	bgColor := parseHexColor(key.HexColor)
 
 	// Create background image
-	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	img := image.NewRGBA(image.Rect(0, 0, key.Width, key.Height))
 	draw.Draw(img, img.Bounds(), &image.Uniform{bgColor}, image.Point{}, draw.Src)
 
-	// If list has an emoji, fetch and composite it
-	if list.Emoji != nil && *list.Emoji != "" {
-		emojiImg, err := fetchEmojiImage(*list.Emoji)
-		if err == nil && emojiImg != nil {
-			// Calculate emoji size and position (80% of icon size, centered)
-			emojiSize := int(float64(size) * 0.7)
-			offset := (size - emojiSize) / 2
-
-			// Scale emoji to fit
-			scaled := image.NewRGBA(image.Rect(0, 0, emojiSize, emojiSize))
-			xdraw.CatmullRom.Scale(scaled, scaled.Bounds(), emojiImg, emojiImg.Bounds(), xdraw.Over, nil)
-
-			// Composite onto background
-			draw.Draw(img, image.Rect(offset, offset, offset+emojiSize, offset+emojiSize),
-				scaled, image.Point{}, draw.Over)
-		}
+	emojiSize := int(float64(min(key.Width, key.Height)) * emojiScale)
+	x := (key.Width - emojiSize) / 2
+	y := (key.Height - emojiSize) / 2
+	emojiRect := image.Rect(x, y, x+emojiSize, y+emojiSize)
+
+	if initials, ok := strings.CutPrefix(key.Content, "monogram:"); ok {
+		drawMonogram(img, emojiRect, initials, contrastColor(bgColor))
+	} else if emojiImg, ok := renderEmoji(key.Content, emojiSize); ok {
+		draw.Draw(img, emojiRect, emojiImg, image.Point{}, draw.Over)
 	}
+	return img
+}
+
+// renderIconSVG draws the icon as SVG, clipped to its shape (key.Variant)
+// The emoji's Twemoji SVG is nested as is, so it stays vector; a monogram becomes <text>
+func renderIconSVG(key iconKey, emojiScale float64) []byte {
+	size := float64(key.Width)
+	bgColor := parseHexColor(key.HexColor)
 
-	// Set headers
-	w.Header().Set("Content-Type", "image/png")
-	w.Header().Set("Cache-Control", "public, max-age=86400") // Cache 24 hours
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`,
+		key.Width, key.Height, key.Width, key.Height)
+	if key.Variant == "circle" {
+		fmt.Fprintf(&buf, `<circle cx="%.4g" cy="%.4g" r="%.4g" fill="#%02x%02x%02x"/>`,
+			size/2, size/2, size/2, bgColor.R, bgColor.G, bgColor.B)
+	} else {
+		fmt.Fprintf(&buf, `<rect width="%d" height="%d" rx="%.4g" fill="#%02x%02x%02x"/>`,
+			key.Width, key.Height, size*iconCornerRadius, bgColor.R, bgColor.G, bgColor.B)
+	}
 
-	// Encode and send
-	png.Encode(w, img)
+	emojiSize := size * emojiScale
+	offset := (size - emojiSize) / 2
+	if initials, ok := strings.CutPrefix(key.Content, "monogram:"); ok {
+		fg := contrastColor(bgColor)
+		// Same proportions as drawMonogram
+		fontSize := emojiSize * 0.5
+		if len([]rune(initials)) > 1 {
+			fontSize = emojiSize * 0.4
+		}
+		fmt.Fprintf(&buf, `<text x="50%%" y="50%%" text-anchor="middle" dominant-baseline="central" `+
+			`font-family="Helvetica, Arial, sans-serif" font-weight="bold" font-size="%.4g" fill="#%02x%02x%02x">`,
+			fontSize, fg.R, fg.G, fg.B)
+		xml.EscapeText(&buf, []byte(initials))
+		buf.WriteString(`</text>`)
+	} else if emoji, ok := emojiSVG(key.Content); ok {
+		// Position the nested <svg> by adding x/y/width/height to its root element
+		attrs := fmt.Sprintf(`<svg x="%.4g" y="%.4g" width="%.4g" height="%.4g" `, offset, offset, emojiSize, emojiSize)
+		buf.Write(bytes.Replace(emoji, []byte("<svg "), []byte(attrs), 1))
+	}
+	buf.WriteString(`</svg>`)
+	return buf.Bytes()
+}
+
+// encodePNG encodes an image as PNG bytes
+func encodePNG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
 }
 
 // GetListManifest returns a dynamic PWA manifest for a specific list
@@ -97,101 +169,142 @@ func GetListManifest(w http.ResponseWriter, r *http.Request) {
 	// Fetch list from database
 	var list List
 	err := DB.QueryRow(context.Background(),
-		"SELECT id, name, emoji, hex_color, created_at FROM lists WHERE id = $1",
-		listID).Scan(&list.ID, &list.Name, &list.Emoji, &list.HexColor, &list.CreatedAt)
+		"SELECT id, name, emoji, hex_color, is_private, created_at FROM lists WHERE id = $1",
+		listID).Scan(&list.ID, &list.Name, &list.Emoji, &list.HexColor, &list.IsPrivate, &list.CreatedAt)
 	if err != nil {
 		http.Error(w, "List not found", http.StatusNotFound)
 		return
 	}
 
 	// Get the API base URL for icon paths
-	scheme := "https"
-	if r.TLS == nil && !strings.Contains(r.Host, "railway") {
-		scheme = "http"
-	}
-	apiBaseURL := fmt.Sprintf("%s://%s", scheme, r.Host)
-
-	// Get frontend URL from Referer header or CORS_ORIGIN env var
-	// This is needed because start_url must be absolute for iOS
-	frontendURL := os.Getenv("CORS_ORIGIN")
-	if frontendURL == "" || frontendURL == "*" {
-		// Try to get from Referer header
-		if referer := r.Header.Get("Referer"); referer != "" {
-			if u, err := url.Parse(referer); err == nil {
-				frontendURL = fmt.Sprintf("%s://%s", u.Scheme, u.Host)
-			}
-		}
-	}
-	// Fallback to localhost for development
-	if frontendURL == "" {
-		frontendURL = "http://localhost:5173"
-	}
+	apiBaseURL := apiBaseURL(r)
+
+	// start_url must be absolute for iOS
+	frontendURL := frontendBaseURL()
+	listURL := fmt.Sprintf("%s/list/%s", frontendURL, listID)
+	text := manifestText(requestLanguage(r))
 
 	// Build manifest with absolute URLs for iOS compatibility
 	manifest := map[string]interface{}{
 		"name":             fmt.Sprintf("JORLIST - %s", list.Name),
 		"short_name":       list.Name,
-		"description":      "Share a list with friends",
-		"start_url":        fmt.Sprintf("%s/list/%s", frontendURL, listID),
+		"description":      text["description"],
+		"lang":             text["lang"],
+		"start_url":        listURL,
 		"scope":            fmt.Sprintf("%s/", frontendURL),
 		"display":          "standalone",
 		"theme_color":      fmt.Sprintf("#%s", list.HexColor),
 		"background_color": fmt.Sprintf("#%s", list.HexColor),
+		// Long-press menu on the home screen icon; the list page reads ?action=
+		"shortcuts": []map[string]interface{}{
+			{
+				"name":  text["add_item"],
+				"url":   listURL + "?action=add",
+				"icons": []map[string]string{{"src": fmt.Sprintf("%s/api/lists/%s/icon/96.png", apiBaseURL, listID), "sizes": "96x96"}},
+			},
+			{
+				"name":  text["shopping_trip"],
+				"url":   listURL + "?action=shop",
+				"icons": []map[string]string{{"src": fmt.Sprintf("%s/api/lists/%s/icon/96.png", apiBaseURL, listID), "sizes": "96x96"}},
+			},
+		},
+		// Text shared from other apps opens the list page, which sends it to AddSharedItems
+		"share_target": map[string]interface{}{
+			"action": listURL,
+			"method": "GET",
+			"params": map[string]string{
+				"title": "share_title",
+				"text":  "share_text",
+				"url":   "share_url",
+			},
+		},
 		"icons": []map[string]string{
 			{
 				"src":     fmt.Sprintf("%s/api/lists/%s/icon/192.png", apiBaseURL, listID),
 				"sizes":   "192x192",
 				"type":    "image/png",
-				"purpose": "any maskable",
+				"purpose": "any",
 			},
 			{
 				"src":     fmt.Sprintf("%s/api/lists/%s/icon/512.png", apiBaseURL, listID),
 				"sizes":   "512x512",
 				"type":    "image/png",
-				"purpose": "any maskable",
+				"purpose": "any",
+			},
+			{
+				"src":     fmt.Sprintf("%s/api/lists/%s/icon/192-maskable.png", apiBaseURL, listID),
+				"sizes":   "192x192",
+				"type":    "image/png",
+				"purpose": "maskable",
+			},
+			{
+				"src":     fmt.Sprintf("%s/api/lists/%s/icon/512-maskable.png", apiBaseURL, listID),
+				"sizes":   "512x512",
+				"type":    "image/png",
+				"purpose": "maskable",
 			},
 		},
 	}
 
 	w.Header().Set("Content-Type", "application/manifest+json")
 	w.Header().Set("Cache-Control", "no-cache") // Always fetch fresh manifest
+	w.Header().Set("Vary", "Accept-Language")
 	json.NewEncoder(w).Encode(manifest)
 }
 
-// parseHexColor converts a 6-character hex string to color.RGBA
-func parseHexColor(hex string) color.RGBA {
-	var r, g, b uint8
-	fmt.Sscanf(hex, "%02x%02x%02x", &r, &g, &b)
-	return color.RGBA{r, g, b, 255}
+// manifestTexts are the manifest's user-visible strings per language
+var manifestTexts = map[string]map[string]string{
+	"en": {
+		"lang":          "en",
+		"description":   "Share a list with friends",
+		"add_item":      "Add item",
+		"shopping_trip": "Start shopping trip",
+	},
+	"de": {
+		"lang":          "de",
+		"description":   "Teile eine Liste mit Freunden",
+		"add_item":      "Eintrag hinzufügen",
+		"shopping_trip": "Einkauf starten",
+	},
 }
 
-// fetchEmojiImage fetches an emoji PNG from the emoji CDN
-func fetchEmojiImage(emoji string) (image.Image, error) {
-	// URL encode the emoji
-	encodedEmoji := url.PathEscape(emoji)
-	emojiURL := fmt.Sprintf("https://emojicdn.elk.sh/%s?style=apple", encodedEmoji)
-
-	resp, err := http.Get(emojiURL)
-	if err != nil {
-		return nil, err
+// manifestText returns the manifest strings for a language, English if there are none for it
+func manifestText(lang string) map[string]string {
+	if text, ok := manifestTexts[lang]; ok {
+		return text
 	}
-	defer resp.Body.Close()
+	return manifestTexts["en"]
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to fetch emoji: %d", resp.StatusCode)
+// apiBaseURL returns the absolute URL of this API, e.g. for icon links
+func apiBaseURL(r *http.Request) string {
+	scheme := "https"
+	if r.TLS == nil && !strings.Contains(r.Host, "railway") {
+		scheme = "http"
 	}
+	return fmt.Sprintf("%s://%s", scheme, r.Host)
+}
 
-	// Read body with size limit (1MB max for emoji)
-	body, err := io.ReadAll(io.LimitReader(resp.Body, 1024*1024))
-	if err != nil {
-		return nil, err
-	}
+// Frontend origin when neither FRONTEND_URL nor CORS_ORIGIN names one (the Vite dev server)
+const defaultFrontendURL = "http://localhost:5173"
 
-	// Decode PNG
-	img, _, err := image.Decode(bytes.NewReader(body))
-	if err != nil {
-		return nil, err
+// frontendBaseURL returns the frontend's origin from FRONTEND_URL, or from CORS_ORIGIN if that names one,
+// falling back to the local dev server. Share links, QR codes and the manifest point there, so it is never
+// taken from the request (a Referer would let any site turn the share page into a redirect to itself)
+func frontendBaseURL() string {
+	frontendURL := os.Getenv("FRONTEND_URL")
+	if corsOrigin := os.Getenv("CORS_ORIGIN"); frontendURL == "" && corsOrigin != "*" {
+		frontendURL = corsOrigin
+	}
+	if frontendURL == "" {
+		frontendURL = defaultFrontendURL
 	}
+	return strings.TrimSuffix(frontendURL, "/")
+}
 
-	return img, nil
+// parseHexColor converts a 6-character hex string to color.RGBA
+func parseHexColor(hex string) color.RGBA {
+	var r, g, b uint8
+	fmt.Sscanf(hex, "%02x%02x%02x", &r, &g, &b)
+	return color.RGBA{r, g, b, 255}
 }
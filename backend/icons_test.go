@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/xml"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+func TestParseHexColor(t *testing.T) {
+	tests := []struct {
+		hex  string
+		want color.RGBA
+	}{
+		{"42b883", color.RGBA{0x42, 0xb8, 0x83, 255}},
+		{"FFFFFF", color.RGBA{255, 255, 255, 255}},
+		{"", color.RGBA{0, 0, 0, 255}},
+	}
+	for _, tt := range tests {
+		if got := parseHexColor(tt.hex); got != tt.want {
+			t.Errorf("parseHexColor(%q) = %v, want %v", tt.hex, got, tt.want)
+		}
+	}
+}
+
+func TestRenderIconSVG(t *testing.T) {
+	tests := []struct {
+		name     string
+		key      iconKey
+		contains []string
+	}{
+		{"rounded emoji icon",
+			iconKey{Content: "🛒", HexColor: "42B883", Width: 192, Height: 192, Variant: "rounded"},
+			[]string{`<rect width="192" height="192"`, `fill="#42b883"`, `<svg x="`}},
+		{"circle monogram",
+			iconKey{Content: "monogram:WG", HexColor: "ffeb3b", Width: 512, Height: 512, Variant: "circle"},
+			[]string{`<circle cx="256" cy="256" r="256" fill="#ffeb3b"/>`, `fill="#000000">WG</text>`}},
+		{"monogram is escaped",
+			iconKey{Content: "monogram:<&", HexColor: "1e3a8a", Width: 64, Height: 64, Variant: "any"},
+			[]string{`fill="#ffffff">&lt;&amp;</text>`}},
+		{"fill only ever comes from the parsed colour",
+			iconKey{Content: "🛒", HexColor: `0"/><script>alert(1)</script>`, Width: 64, Height: 64, Variant: "any"},
+			[]string{`fill="#000000"`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svg := string(renderIconSVG(tt.key, iconEmojiScale[tt.key.Variant]))
+			for _, s := range tt.contains {
+				if !strings.Contains(svg, s) {
+					t.Errorf("SVG doesn't contain %s:\n%s", s, svg)
+				}
+			}
+			if strings.Contains(svg, "<script") {
+				t.Errorf("SVG contains a script:\n%s", svg)
+			}
+			if err := xml.Unmarshal([]byte(svg), new(struct{})); err != nil {
+				t.Errorf("SVG isn't well-formed: %v", err)
+			}
+		})
+	}
+}
+
+func TestFrontendBaseURL(t *testing.T) {
+	tests := []struct {
+		frontendURL, corsOrigin string
+		want                    string
+	}{
+		{"https://app.example.com", "", "https://app.example.com"},
+		{"https://app.example.com/", "https://other.example.com", "https://app.example.com"},
+		{"", "https://app.example.com", "https://app.example.com"},
+		{"", "*", defaultFrontendURL},
+		{"", "", defaultFrontendURL},
+	}
+	for _, tt := range tests {
+		t.Setenv("FRONTEND_URL", tt.frontendURL)
+		t.Setenv("CORS_ORIGIN", tt.corsOrigin)
+		if got := frontendBaseURL(); got != tt.want {
+			t.Errorf("FRONTEND_URL=%q CORS_ORIGIN=%q: %q, want %q", tt.frontendURL, tt.corsOrigin, got, tt.want)
+		}
+	}
+}
+
+func TestManifestText(t *testing.T) {
+	tests := []struct {
+		lang, want string
+	}{
+		{"en", "en"},
+		{"de", "de"},
+		{"fr", "en"},
+		{"", "en"},
+	}
+	for _, tt := range tests {
+		if got := manifestText(tt.lang)["lang"]; got != tt.want {
+			t.Errorf("manifestText(%q) is in %q, want %q", tt.lang, got, tt.want)
+		}
+	}
+
+	// Every language needs every string the English manifest has
+	for lang, text := range manifestTexts {
+		for key := range manifestTexts["en"] {
+			if text[key] == "" {
+				t.Errorf("%s has no %q", lang, key)
+			}
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"math"
+	"time"
+)
+
+// Interval model settings
+const (
+	intervalAlpha        = 0.3 // weight of the newest interval in the moving average
+	readdDebounceMinutes = 10  // re-adds closer together than this count as one addition
+)
+
+// applyAddition updates a history entry's interval model for an addition at the given time
+// This mirrors the UPDATE in trackItemAddition, so timelines can be replayed in Go
+func applyAddition(h *ItemHistory, at time.Time) {
+	if h.AddedCount == 0 {
+		h.AddedCount = 1
+		h.LastAddedAt = at
+		return
+	}
+	if at.Sub(h.LastAddedAt) < readdDebounceMinutes*time.Minute {
+		return
+	}
+
+	days := at.Sub(h.LastAddedAt).Hours() / 24
+	if h.IntervalEWMA == nil {
+		avg, ewma, variance := days, days, 0.0
+		h.AvgDaysBetween, h.IntervalEWMA, h.IntervalVariance = &avg, &ewma, &variance
+	} else {
+		avg := (*h.AvgDaysBetween*float64(h.AddedCount-1) + days) / float64(h.AddedCount)
+		diff := days - *h.IntervalEWMA
+		ewma := *h.IntervalEWMA + intervalAlpha*diff
+		variance := (1 - intervalAlpha) * (valueOr(h.IntervalVariance, 0) + intervalAlpha*diff*diff)
+		h.AvgDaysBetween, h.IntervalEWMA, h.IntervalVariance = &avg, &ewma, &variance
+	}
+	h.AddedCount++
+	h.LastAddedAt = at
+}
+
+// expectedInterval returns the best estimate of days between additions, or 0 if there's no interval yet
+func expectedInterval(h ItemHistory) float64 {
+	if h.IntervalEWMA != nil && *h.IntervalEWMA > 0 {
+		return *h.IntervalEWMA
+	}
+	return valueOr(h.AvgDaysBetween, 0)
+}
+
+// intervalConfidence rates how predictable an item is, from 0 (no idea) towards 1
+// It grows with the number of observed intervals and shrinks with their spread (coefficient of variation)
+func intervalConfidence(h ItemHistory) float64 {
+	intervals := h.AddedCount - 1
+	mean := expectedInterval(h)
+	if intervals < 1 || mean <= 0 {
+		return 0
+	}
+
+	// Without a variance (rows migrated from the old model) assume a fairly irregular item
+	cv := 1.0
+	if h.IntervalVariance != nil {
+		cv = math.Sqrt(*h.IntervalVariance) / mean
+	}
+
+	sampleFactor := float64(intervals) / float64(intervals+1)
+	return sampleFactor / (1 + cv)
+}
+
+// valueOr dereferences p, or returns def for nil
+func valueOr(p *float64, def float64) float64 {
+	if p == nil {
+		return def
+	}
+	return *p
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestApplyAddition(t *testing.T) {
+	start := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+	days := func(d float64) time.Time { return start.Add(time.Duration(d * 24 * float64(time.Hour))) }
+
+	tests := []struct {
+		name                string
+		additions           []time.Time
+		wantCount           int
+		wantAvg, wantEWMA   float64
+		wantVariance        float64
+		wantNoInterval      bool
+		wantLastAddedAtDays float64
+	}{
+		{name: "first addition", additions: []time.Time{days(0)}, wantCount: 1, wantNoInterval: true},
+		{name: "second addition starts the model", additions: []time.Time{days(0), days(7)},
+			wantCount: 2, wantAvg: 7, wantEWMA: 7, wantVariance: 0, wantLastAddedAtDays: 7},
+		{name: "regular intervals", additions: []time.Time{days(0), days(7), days(14)},
+			wantCount: 3, wantAvg: 7, wantEWMA: 7, wantVariance: 0, wantLastAddedAtDays: 14},
+		{name: "a longer interval moves the average by alpha", additions: []time.Time{days(0), days(7), days(14), days(24)},
+			wantCount: 4, wantAvg: 8, wantEWMA: 7 + intervalAlpha*3, wantVariance: (1 - intervalAlpha) * intervalAlpha * 9,
+			wantLastAddedAtDays: 24},
+		{name: "quick re-adds are ignored", additions: []time.Time{days(0), days(0).Add(5 * time.Minute), days(7), days(7).Add(time.Minute)},
+			wantCount: 2, wantAvg: 7, wantEWMA: 7, wantVariance: 0, wantLastAddedAtDays: 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var h ItemHistory
+			for _, at := range tt.additions {
+				applyAddition(&h, at)
+			}
+			if h.AddedCount != tt.wantCount {
+				t.Errorf("AddedCount = %d, want %d", h.AddedCount, tt.wantCount)
+			}
+			if tt.wantNoInterval {
+				if h.IntervalEWMA != nil || h.AvgDaysBetween != nil {
+					t.Errorf("got an interval after one addition")
+				}
+				return
+			}
+			check := func(field string, got *float64, want float64) {
+				if got == nil || math.Abs(*got-want) > 1e-9 {
+					t.Errorf("%s = %v, want %v", field, valueOr(got, math.NaN()), want)
+				}
+			}
+			check("AvgDaysBetween", h.AvgDaysBetween, tt.wantAvg)
+			check("IntervalEWMA", h.IntervalEWMA, tt.wantEWMA)
+			check("IntervalVariance", h.IntervalVariance, tt.wantVariance)
+			if want := days(tt.wantLastAddedAtDays); !h.LastAddedAt.Equal(want) {
+				t.Errorf("LastAddedAt = %s, want %s", h.LastAddedAt, want)
+			}
+		})
+	}
+}
+
+func TestIntervalConfidence(t *testing.T) {
+	history := func(intervals ...float64) ItemHistory {
+		at := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		h := ItemHistory{}
+		applyAddition(&h, at)
+		for _, d := range intervals {
+			at = at.Add(time.Duration(d * 24 * float64(time.Hour)))
+			applyAddition(&h, at)
+		}
+		return h
+	}
+
+	if got := intervalConfidence(history()); got != 0 {
+		t.Errorf("confidence without an interval = %v, want 0", got)
+	}
+	regular := intervalConfidence(history(7, 7, 7, 7))
+	irregular := intervalConfidence(history(2, 14, 3, 12))
+	fewer := intervalConfidence(history(7))
+	if regular <= irregular {
+		t.Errorf("regular item (%v) should be more confident than an irregular one (%v)", regular, irregular)
+	}
+	if regular <= fewer {
+		t.Errorf("four intervals (%v) should be more confident than one (%v)", regular, fewer)
+	}
+	if regular <= 0 || regular >= 1 {
+		t.Errorf("confidence %v out of (0, 1)", regular)
+	}
+
+	// Rows from before the variance was tracked count as fairly irregular
+	avg := 7.0
+	migrated := ItemHistory{AddedCount: 5, AvgDaysBetween: &avg}
+	if got, want := intervalConfidence(migrated), 0.8/2; math.Abs(got-want) > 1e-9 {
+		t.Errorf("confidence without variance = %v, want %v", got, want)
+	}
+}
+
+func TestExpectedInterval(t *testing.T) {
+	avg, ewma, zero := 10.0, 6.0, 0.0
+	tests := []struct {
+		h    ItemHistory
+		want float64
+	}{
+		{ItemHistory{}, 0},
+		{ItemHistory{AvgDaysBetween: &avg}, 10},
+		{ItemHistory{AvgDaysBetween: &avg, IntervalEWMA: &ewma}, 6},
+		{ItemHistory{AvgDaysBetween: &avg, IntervalEWMA: &zero}, 10},
+	}
+	for _, tt := range tests {
+		if got := expectedInterval(tt.h); got != tt.want {
+			t.Errorf("expectedInterval(avg=%v, ewma=%v) = %v, want %v",
+				valueOr(tt.h.AvgDaysBetween, -1), valueOr(tt.h.IntervalEWMA, -1), got, tt.want)
+		}
+	}
+}
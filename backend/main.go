@@ -36,6 +36,21 @@ func main() {
 	ConnectDB()
 	defer CloseDB() // This runs when main() exits
 
+	// Run an admin command instead of the server if one was given (see commands.go)
+	if len(os.Args) > 1 {
+		runCommand(os.Args[1:])
+		return
+	}
+
+	// Configure passkey sign-in
+	InitWebAuthn()
+
+	// Re-add recurring items in the background
+	go StartRecurrenceScheduler()
+
+	// Aggregate item popularity for cold-start suggestions
+	go StartPopularityRefresher()
+
 	// Create a new router (Go 1.22+ has built-in routing with path parameters)
 	mux := http.NewServeMux()
 
@@ -45,21 +60,90 @@ func main() {
 	mux.HandleFunc("GET /api/lists/{id}", GetList)
 	mux.HandleFunc("PATCH /api/lists/{id}", UpdateList)
 	mux.HandleFunc("DELETE /api/lists/{id}", DeleteList)
+	mux.HandleFunc("POST /api/lists/{id}/claim", ClaimList)
+	mux.HandleFunc("POST /api/lists/{id}/duplicate", DuplicateList)
+
+	// Template routes
+	mux.HandleFunc("GET /api/templates", GetTemplates)
+	mux.HandleFunc("POST /api/templates", CreateTemplate)
+	mux.HandleFunc("GET /api/templates/{id}", GetTemplate)
+	mux.HandleFunc("DELETE /api/templates/{id}", DeleteTemplate)
+	mux.HandleFunc("POST /api/templates/{id}/instantiate", InstantiateTemplate)
+	mux.HandleFunc("POST /api/templates/{id}/merge", MergeTemplate)
 
 	// Item routes (nested under lists for security - verifies list ownership)
 	mux.HandleFunc("GET /api/lists/{listId}/items", GetItems)
 	mux.HandleFunc("POST /api/lists/{listId}/items", CreateItem)
 	mux.HandleFunc("PUT /api/lists/{listId}/items/reorder", ReorderItems)
+	mux.HandleFunc("PUT /api/lists/{listId}/items/{id}/position", MoveItemPosition)
+	mux.HandleFunc("POST /api/lists/{listId}/items/move", MoveItems)
+	mux.HandleFunc("POST /api/lists/{listId}/items/shared", AddSharedItems)
+	mux.HandleFunc("POST /api/lists/{listId}/items/batch", BatchItems)
+	mux.HandleFunc("POST /api/lists/{listId}/items/clear-checked", ClearCheckedItems)
+	mux.HandleFunc("POST /api/lists/{listId}/items/uncheck-all", UncheckAllItems)
+	mux.HandleFunc("DELETE /api/lists/{listId}/items", DeleteAllItems)
+	mux.HandleFunc("POST /api/lists/{listId}/items/{id}/move", MoveItem)
 	mux.HandleFunc("PATCH /api/lists/{listId}/items/{id}", UpdateItem)
 	mux.HandleFunc("DELETE /api/lists/{listId}/items/{id}", DeleteItem)
 
+	// Section routes (named, collapsible groups of items)
+	mux.HandleFunc("GET /api/lists/{listId}/sections", GetSections)
+	mux.HandleFunc("POST /api/lists/{listId}/sections", CreateSection)
+	mux.HandleFunc("PATCH /api/lists/{listId}/sections/{id}", UpdateSection)
+	mux.HandleFunc("DELETE /api/lists/{listId}/sections/{id}", DeleteSection)
+
+	// Recurring item routes
+	mux.HandleFunc("PUT /api/lists/{listId}/items/{id}/recurrence", SetItemRecurrence)
+	mux.HandleFunc("GET /api/lists/{listId}/recurrences", GetRecurrences)
+	mux.HandleFunc("DELETE /api/lists/{listId}/recurrences/{id}", DeleteRecurrence)
+
 	// Recommendations routes
 	mux.HandleFunc("GET /api/lists/{listId}/recommendations", GetRecommendations)
 	mux.HandleFunc("POST /api/lists/{listId}/recommendations/{name}/dismiss", DismissRecommendation)
+	mux.HandleFunc("POST /api/lists/{listId}/recommendations/{name}/snooze", SnoozeRecommendation)
+	mux.HandleFunc("POST /api/lists/{listId}/recommendations/{name}/restore", RestoreRecommendation)
+	mux.HandleFunc("GET /api/lists/{listId}/recommendations/hidden", GetHiddenRecommendations)
+	mux.HandleFunc("GET /api/lists/{listId}/items/{id}/related", GetRelatedItems)
+	mux.HandleFunc("POST /api/admin/backfill-history", BackfillHistory)
+	mux.HandleFunc("GET /api/lists/{listId}/synonyms", GetSynonyms)
+	mux.HandleFunc("POST /api/lists/{listId}/synonyms", CreateSynonym)
+	mux.HandleFunc("DELETE /api/lists/{listId}/synonyms/{alias}", DeleteSynonym)
+
+	// Workspace routes (access via capability token or membership)
+	mux.HandleFunc("POST /api/workspaces", CreateWorkspace)
+	mux.HandleFunc("GET /api/workspaces/{id}", GetWorkspace)
+	mux.HandleFunc("PATCH /api/workspaces/{id}", UpdateWorkspace)
+	mux.HandleFunc("DELETE /api/workspaces/{id}", DeleteWorkspace)
+	mux.HandleFunc("POST /api/workspaces/{id}/lists", AddWorkspaceList)
+	mux.HandleFunc("DELETE /api/workspaces/{id}/lists/{listId}", RemoveWorkspaceList)
+	mux.HandleFunc("GET /api/workspaces/{id}/lists/{ref}/items", GetWorkspaceListItems)
+	mux.HandleFunc("GET /api/workspaces/{id}/tokens", GetWorkspaceTokens)
+	mux.HandleFunc("POST /api/workspaces/{id}/tokens", CreateWorkspaceTokenHandler)
+	mux.HandleFunc("DELETE /api/workspaces/{id}/tokens/{tokenId}", RevokeWorkspaceToken)
+	mux.HandleFunc("POST /api/workspaces/{id}/members", JoinWorkspace)
+	mux.HandleFunc("DELETE /api/workspaces/{id}/members/{userId}", RemoveWorkspaceMember)
+
+	// Passkey (WebAuthn) account routes
+	mux.HandleFunc("POST /api/auth/passkeys/register/begin", BeginPasskeyRegistration)
+	mux.HandleFunc("POST /api/auth/passkeys/register/finish", FinishPasskeyRegistration)
+	mux.HandleFunc("POST /api/auth/passkeys/login/begin", BeginPasskeyLogin)
+	mux.HandleFunc("POST /api/auth/passkeys/login/finish", FinishPasskeyLogin)
+	mux.HandleFunc("DELETE /api/auth/passkeys/{id}", DeletePasskey)
+	mux.HandleFunc("POST /api/auth/logout", Logout)
+	mux.HandleFunc("GET /api/auth/me", GetMe)
 
 	// PWA routes (dynamic icons and manifest)
 	mux.HandleFunc("GET /api/lists/{listId}/icon/{size}", GetListIcon)
 	mux.HandleFunc("GET /api/lists/{listId}/manifest.webmanifest", GetListManifest)
+	mux.HandleFunc("GET /api/lists/{listId}/favicon.ico", GetListFavicon)
+	mux.HandleFunc("GET /api/lists/{listId}/splash-screens", GetListSplashScreens)
+	mux.HandleFunc("GET /api/lists/{listId}/splash/{size}", GetListSplash)
+
+	// Link previews for messengers (Open Graph) and QR codes
+	mux.HandleFunc("GET /api/lists/{listId}/preview.png", GetListPreview)
+	mux.HandleFunc("GET /share/{listId}", ShareList)
+	mux.HandleFunc("GET /api/lists/{listId}/qr.png", GetListQR)
+	mux.HandleFunc("GET /api/lists/{listId}/qr.svg", GetListQR)
 
 	// Health check endpoint (useful for deployment platforms)
 	mux.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
@@ -91,7 +175,7 @@ func corsMiddleware(next http.Handler) http.Handler {
 
 		w.Header().Set("Access-Control-Allow-Origin", allowedOrigin)
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Workspace-Token")
 
 		// Handle preflight requests (browsers send OPTIONS before actual request)
 		if r.Method == "OPTIONS" {
@@ -0,0 +1,45 @@
+-- Passkey (WebAuthn) accounts
+-- Run this SQL in your Supabase SQL editor to enable passkey sign-in
+
+-- 1. Accounts - only created when someone registers a passkey
+CREATE TABLE IF NOT EXISTS users (
+    id VARCHAR(32) PRIMARY KEY DEFAULT substr(gen_random_uuid()::text, 1, 32),
+    webauthn_id BYTEA NOT NULL UNIQUE,
+    display_name VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- 2. Registered passkeys (the credential column holds the full WebAuthn credential record)
+CREATE TABLE IF NOT EXISTS passkey_credentials (
+    id BYTEA PRIMARY KEY,
+    user_id VARCHAR(32) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    credential JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    last_used_at TIMESTAMP WITH TIME ZONE
+);
+
+-- 3. Pending registration/login ceremonies (challenge data between begin and finish)
+CREATE TABLE IF NOT EXISTS webauthn_ceremonies (
+    id VARCHAR(64) PRIMARY KEY,
+    kind VARCHAR(16) NOT NULL,
+    user_id VARCHAR(32) REFERENCES users(id) ON DELETE CASCADE,
+    session JSONB NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- 4. Sign-in sessions (only a SHA-256 hash of the bearer token is stored)
+CREATE TABLE IF NOT EXISTS user_sessions (
+    token_hash BYTEA PRIMARY KEY,
+    user_id VARCHAR(32) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- 5. Lists can be claimed by an account
+ALTER TABLE lists
+ADD COLUMN IF NOT EXISTS owner_id VARCHAR(32) REFERENCES users(id) ON DELETE SET NULL;
+
+-- Indexes for faster lookups
+CREATE INDEX IF NOT EXISTS idx_passkey_credentials_user_id ON passkey_credentials(user_id);
+CREATE INDEX IF NOT EXISTS idx_user_sessions_user_id ON user_sessions(user_id);
+CREATE INDEX IF NOT EXISTS idx_lists_owner_id ON lists(owner_id);
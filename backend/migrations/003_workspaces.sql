@@ -0,0 +1,46 @@
+-- Workspaces (households) that group several lists under one shared link
+-- Run this SQL in your Supabase SQL editor after 002_passkeys.sql
+
+-- 1. The workspace itself
+CREATE TABLE IF NOT EXISTS workspaces (
+    id VARCHAR(32) PRIMARY KEY DEFAULT substr(gen_random_uuid()::text, 1, 32),
+    name VARCHAR(30) NOT NULL,
+    emoji TEXT,
+    hex_color VARCHAR(6) DEFAULT '42b883',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- 2. Lists in a workspace (a list can belong to several workspaces)
+CREATE TABLE IF NOT EXISTS workspace_lists (
+    workspace_id VARCHAR(32) NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    list_id VARCHAR(32) NOT NULL REFERENCES lists(id) ON DELETE CASCADE,
+    sort_order FLOAT DEFAULT 0,
+    added_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (workspace_id, list_id)
+);
+
+-- 3. Signed-in members (passkey accounts)
+CREATE TABLE IF NOT EXISTS workspace_members (
+    workspace_id VARCHAR(32) NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    user_id VARCHAR(32) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(10) NOT NULL DEFAULT 'member' CHECK (role IN ('owner', 'member')),
+    joined_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (workspace_id, user_id)
+);
+
+-- 4. Capability tokens - the secret part of a shared workspace link
+-- Only a SHA-256 hash of the token is stored
+CREATE TABLE IF NOT EXISTS workspace_tokens (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workspace_id VARCHAR(32) NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    token_hash BYTEA NOT NULL UNIQUE,
+    capability VARCHAR(10) NOT NULL CHECK (capability IN ('view', 'edit', 'admin')),
+    label VARCHAR(50),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    revoked_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Indexes for faster lookups
+CREATE INDEX IF NOT EXISTS idx_workspace_lists_list_id ON workspace_lists(list_id);
+CREATE INDEX IF NOT EXISTS idx_workspace_members_user_id ON workspace_members(user_id);
+CREATE INDEX IF NOT EXISTS idx_workspace_tokens_workspace_id ON workspace_tokens(workspace_id);
@@ -0,0 +1,54 @@
+-- Reusable list templates (e.g. "BBQ party", "Weekly basics")
+-- Run this SQL in your Supabase SQL editor to enable templates
+
+-- 1. Templates - built-in ones are shown to everyone, user templates are only reachable by ID (like lists)
+CREATE TABLE IF NOT EXISTS list_templates (
+    id VARCHAR(32) PRIMARY KEY DEFAULT substr(gen_random_uuid()::text, 1, 32),
+    name VARCHAR(15) NOT NULL,
+    emoji TEXT,
+    hex_color VARCHAR(6) DEFAULT '42b883',
+    language VARCHAR(5) NOT NULL DEFAULT 'en',
+    is_builtin BOOLEAN DEFAULT false,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- 2. Template items
+CREATE TABLE IF NOT EXISTS template_items (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    template_id VARCHAR(32) NOT NULL REFERENCES list_templates(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    is_separator BOOLEAN DEFAULT false,
+    sort_order FLOAT DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_template_items_template_id ON template_items(template_id, sort_order);
+
+-- 3. Built-in templates
+INSERT INTO list_templates (id, name, emoji, hex_color, language, is_builtin) VALUES
+    ('bbq-party', 'BBQ party', '🍖', 'e67e22', 'en', true),
+    ('weekly-basics', 'Weekly basics', '🛒', '42b883', 'en', true),
+    ('grillparty', 'Grillparty', '🍖', 'e67e22', 'de', true),
+    ('wocheneinkauf', 'Wocheneinkauf', '🛒', '42b883', 'de', true)
+ON CONFLICT (id) DO NOTHING;
+
+INSERT INTO template_items (template_id, name, is_separator, sort_order)
+SELECT t.template_id, t.name, t.name = '', t.sort_order
+FROM (VALUES
+    ('bbq-party', 'Sausages', 1), ('bbq-party', 'Burger patties', 2), ('bbq-party', 'Burger buns', 3),
+    ('bbq-party', 'Corn on the cob', 4), ('bbq-party', 'Potato salad', 5), ('bbq-party', 'Ketchup', 6),
+    ('bbq-party', 'Mustard', 7), ('bbq-party', 'Charcoal', 8), ('bbq-party', 'Ice', 9),
+    ('bbq-party', 'Drinks', 10),
+    ('weekly-basics', 'Milk', 1), ('weekly-basics', 'Bread', 2), ('weekly-basics', 'Eggs', 3),
+    ('weekly-basics', 'Butter', 4), ('weekly-basics', 'Cheese', 5), ('weekly-basics', 'Apples', 6),
+    ('weekly-basics', 'Bananas', 7), ('weekly-basics', 'Tomatoes', 8), ('weekly-basics', 'Pasta', 9),
+    ('weekly-basics', 'Coffee', 10),
+    ('grillparty', 'Bratwurst', 1), ('grillparty', 'Burger-Patties', 2), ('grillparty', 'Brötchen', 3),
+    ('grillparty', 'Maiskolben', 4), ('grillparty', 'Kartoffelsalat', 5), ('grillparty', 'Ketchup', 6),
+    ('grillparty', 'Senf', 7), ('grillparty', 'Grillkohle', 8), ('grillparty', 'Eis', 9),
+    ('grillparty', 'Getränke', 10),
+    ('wocheneinkauf', 'Milch', 1), ('wocheneinkauf', 'Brot', 2), ('wocheneinkauf', 'Eier', 3),
+    ('wocheneinkauf', 'Butter', 4), ('wocheneinkauf', 'Käse', 5), ('wocheneinkauf', 'Äpfel', 6),
+    ('wocheneinkauf', 'Bananen', 7), ('wocheneinkauf', 'Tomaten', 8), ('wocheneinkauf', 'Nudeln', 9),
+    ('wocheneinkauf', 'Kaffee', 10)
+) AS t(template_id, name, sort_order)
+WHERE NOT EXISTS (SELECT 1 FROM template_items WHERE template_id = t.template_id);
@@ -0,0 +1,20 @@
+-- Recurring items that come back onto the list on a schedule
+-- Run this SQL in your Supabase SQL editor to enable recurring items
+
+CREATE TABLE IF NOT EXISTS item_recurrences (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    list_id VARCHAR(32) NOT NULL REFERENCES lists(id) ON DELETE CASCADE,
+    -- NULL once the item was deleted - the scheduler re-adds it by name
+    item_id UUID UNIQUE REFERENCES items(id) ON DELETE SET NULL,
+    name VARCHAR(100) NOT NULL,
+    -- RRULE-lite, e.g. FREQ=WEEKLY;INTERVAL=2;BYDAY=MO
+    rule TEXT NOT NULL,
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    next_due_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_fired_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- The scheduler polls by due date
+CREATE INDEX IF NOT EXISTS idx_item_recurrences_due ON item_recurrences(next_due_at);
+CREATE INDEX IF NOT EXISTS idx_item_recurrences_list_id ON item_recurrences(list_id);
@@ -0,0 +1,19 @@
+-- "Often bought together" - per-list item co-occurrence
+-- Run this SQL in your Supabase SQL editor after 001_item_history.sql
+
+-- 1. Shopping trips per list: additions less than a few hours apart belong to the same trip
+CREATE TABLE IF NOT EXISTS list_trips (
+    list_id VARCHAR(32) PRIMARY KEY REFERENCES lists(id) ON DELETE CASCADE,
+    trip_count INTEGER NOT NULL DEFAULT 0,
+    last_activity_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- 2. How often two items were added in the same trip (stored in both directions)
+CREATE TABLE IF NOT EXISTS item_cooccurrence (
+    list_id VARCHAR(32) NOT NULL REFERENCES lists(id) ON DELETE CASCADE,
+    item_a VARCHAR(100) NOT NULL,
+    item_b VARCHAR(100) NOT NULL,
+    count INTEGER NOT NULL DEFAULT 1,
+    last_seen_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (list_id, item_a, item_b)
+);
@@ -0,0 +1,36 @@
+-- Robust purchase-interval model for recommendations
+-- Run this SQL in your Supabase SQL editor after 001_item_history.sql
+
+-- 1. Full timeline of additions per item (re-adds within minutes of each other are not recorded)
+CREATE TABLE IF NOT EXISTS item_additions (
+    id BIGSERIAL PRIMARY KEY,
+    list_id VARCHAR(32) NOT NULL REFERENCES lists(id) ON DELETE CASCADE,
+    item_name VARCHAR(100) NOT NULL,
+    added_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_item_additions_lookup ON item_additions(list_id, item_name, added_at);
+
+-- 2. Exponentially weighted interval estimate and its variance (in days)
+ALTER TABLE item_history
+ADD COLUMN IF NOT EXISTS interval_ewma FLOAT,
+ADD COLUMN IF NOT EXISTS interval_variance FLOAT;
+
+-- 3. avg_days_between no longer starts at a fake 7 days - it stays NULL until there is a real interval
+ALTER TABLE item_history ALTER COLUMN avg_days_between DROP DEFAULT;
+
+-- Remove the seeded 7 from existing averages: avg = (7 + sum of intervals) / added_count
+UPDATE item_history
+SET avg_days_between = GREATEST((avg_days_between * added_count - 7) / (added_count - 1), 0),
+    interval_ewma = GREATEST((avg_days_between * added_count - 7) / (added_count - 1), 0)
+WHERE added_count >= 2 AND interval_ewma IS NULL;
+
+UPDATE item_history SET avg_days_between = NULL WHERE added_count < 2;
+
+-- 4. Seed the timeline with the one addition we know about
+INSERT INTO item_additions (list_id, item_name, added_at)
+SELECT h.list_id, h.item_name, h.last_added_at
+FROM item_history h
+WHERE NOT EXISTS (
+    SELECT 1 FROM item_additions a WHERE a.list_id = h.list_id AND a.item_name = h.item_name
+);
@@ -0,0 +1,24 @@
+-- Item name normalization and per-list synonyms
+-- Run this SQL in your Supabase SQL editor after 007_item_additions.sql
+
+-- 1. Normalized key of each history record ("Milk", "milk " and "milks" share one key)
+-- This only lowercases existing names; run `go run . normalize-history` afterwards
+-- to apply the full normalization and merge duplicate records
+ALTER TABLE item_history ADD COLUMN IF NOT EXISTS name_key VARCHAR(100);
+
+UPDATE item_history SET name_key = lower(btrim(item_name)) WHERE name_key IS NULL;
+
+ALTER TABLE item_history ALTER COLUMN name_key SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_item_history_name_key ON item_history(list_id, name_key);
+
+-- 2. Synonyms: items whose names normalize to alias_key are treated as canonical_key
+CREATE TABLE IF NOT EXISTS list_synonyms (
+    list_id VARCHAR(32) NOT NULL REFERENCES lists(id) ON DELETE CASCADE,
+    alias_key VARCHAR(100) NOT NULL,
+    canonical_key VARCHAR(100) NOT NULL,
+    alias VARCHAR(100) NOT NULL,
+    canonical VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (list_id, alias_key)
+);
@@ -0,0 +1,7 @@
+-- Snoozed and dismissed recommendations
+-- Run this SQL in your Supabase SQL editor after 001_item_history.sql
+
+-- Suggestions are hidden until snoozed_until; dismissed_at records when a suggestion was dismissed
+ALTER TABLE item_history
+ADD COLUMN IF NOT EXISTS snoozed_until TIMESTAMP WITH TIME ZONE,
+ADD COLUMN IF NOT EXISTS dismissed_at TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,17 @@
+-- Global popularity-based suggestions for new lists
+-- Run this SQL in your Supabase SQL editor after 008_item_names.sql
+
+-- 1. Language a list is used in (from the Accept-Language header)
+ALTER TABLE lists ADD COLUMN IF NOT EXISTS language VARCHAR(8);
+
+-- 2. Anonymized aggregate, rebuilt hourly by the backend
+-- Only items added by at least 5 different lists are stored, and no list IDs
+CREATE TABLE IF NOT EXISTS item_popularity (
+    language VARCHAR(8) NOT NULL,
+    name_key VARCHAR(100) NOT NULL,
+    display_name VARCHAR(100) NOT NULL,
+    list_count INTEGER NOT NULL,
+    total_adds INTEGER NOT NULL,
+    language_lists INTEGER NOT NULL,
+    PRIMARY KEY (language, name_key)
+);
@@ -0,0 +1,4 @@
+-- Private lists: share previews show only the item count, not the items
+-- Run this SQL in your Supabase SQL editor after 010_item_popularity.sql
+
+ALTER TABLE lists ADD COLUMN IF NOT EXISTS is_private BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,53 @@
+-- Sections: named, collapsible groups of items, replacing separator items
+-- Run this SQL in your Supabase SQL editor after 011_private_lists.sql
+
+-- 1. Sections per list, ordered among themselves by sort_order
+CREATE TABLE IF NOT EXISTS list_sections (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    list_id VARCHAR(32) NOT NULL REFERENCES lists(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL DEFAULT '',
+    hex_color VARCHAR(6),
+    category VARCHAR(50),             -- items added with this category go into the section
+    collapsed BOOLEAN NOT NULL DEFAULT false,
+    sort_order FLOAT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_list_sections_list_id ON list_sections(list_id, sort_order);
+
+-- 2. Items reference their section; deleting a section keeps its items
+ALTER TABLE items ADD COLUMN IF NOT EXISTS section_id UUID REFERENCES list_sections(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_items_section_id ON items(section_id);
+
+-- 3. Every separator item becomes a section with its name, in the same order
+ALTER TABLE list_sections ADD COLUMN migrated_from TEXT;
+
+INSERT INTO list_sections (list_id, name, sort_order, migrated_from)
+SELECT list_id, LEFT(COALESCE(name, ''), 100),
+       row_number() OVER (PARTITION BY list_id ORDER BY sort_order ASC, created_at DESC),
+       id::text
+FROM items WHERE is_separator;
+
+-- 4. Items go into the section of the closest separator above them (same order as the app)
+WITH ordered AS (
+    SELECT id, list_id, is_separator,
+           COUNT(*) FILTER (WHERE is_separator) OVER (
+               PARTITION BY list_id ORDER BY sort_order ASC, created_at DESC
+               ROWS UNBOUNDED PRECEDING
+           ) AS separators_above
+    FROM items
+),
+separators AS (
+    SELECT list_id, separators_above, id::text AS separator_id FROM ordered WHERE is_separator
+)
+UPDATE items SET section_id = s.id
+FROM ordered o
+JOIN separators sep ON sep.list_id = o.list_id AND sep.separators_above = o.separators_above
+JOIN list_sections s ON s.migrated_from = sep.separator_id
+WHERE items.id = o.id AND NOT o.is_separator;
+
+-- 5. The separators themselves are no longer needed
+DELETE FROM items WHERE is_separator;
+ALTER TABLE list_sections DROP COLUMN migrated_from;
+ALTER TABLE items DROP COLUMN is_separator;
@@ -0,0 +1,7 @@
+-- Sub-items: items nested under another item (e.g. "Cake ingredients" -> flour, eggs, sugar)
+-- Run this SQL in your Supabase SQL editor after 012_sections.sql
+
+-- Deleting a parent deletes its sub-items (the API can keep them instead)
+ALTER TABLE items ADD COLUMN IF NOT EXISTS parent_id UUID REFERENCES items(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_items_parent_id ON items(parent_id);
@@ -0,0 +1,7 @@
+-- One item_history record per item: name_key becomes unique per list
+-- Run this SQL in your Supabase SQL editor after 013_sub_items.sql
+-- If it fails with a duplicate key, run `cd backend && go run . normalize-history` and try again
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_item_history_list_name_key ON item_history(list_id, name_key);
+
+DROP INDEX IF EXISTS idx_item_history_name_key;
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// transferOrder keeps the source order of transferred items (aliased i, with their parent p),
+// each followed by its sub-items
+const transferOrder = `COALESCE(p.sort_order, i.sort_order) ASC, COALESCE(p.created_at, i.created_at) DESC,
+	COALESCE(p.id, i.id), i.parent_id IS NOT NULL, i.sort_order ASC, i.created_at DESC`
+
+// Errors returned by transferItems
+var (
+	errTargetNotFound = errors.New("target list not found")
+	errItemsNotFound  = errors.New("items not found")
+)
+
+// MoveItem handles POST /api/lists/{listId}/items/{id}/move - moves or copies one item to another list
+func MoveItem(w http.ResponseWriter, r *http.Request) {
+	listID := r.PathValue("listId")
+	id := r.PathValue("id")
+	if listID == "" || id == "" {
+		http.Error(w, "List ID and Item ID are required", http.StatusBadRequest)
+		return
+	}
+
+	var input struct {
+		TargetListID string `json:"target_list_id"`
+		Copy         bool   `json:"copy"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	items, ok := handleTransfer(w, listID, input.TargetListID, []string{id}, input.Copy)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if input.Copy {
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(items[0])
+}
+
+// MoveItems handles POST /api/lists/{listId}/items/move - moves or copies several items to another list
+// Items keep their relative order and are appended to the end of the target list
+func MoveItems(w http.ResponseWriter, r *http.Request) {
+	listID := r.PathValue("listId")
+	if listID == "" {
+		http.Error(w, "List ID is required", http.StatusBadRequest)
+		return
+	}
+
+	var input struct {
+		ItemIDs      []string `json:"item_ids"`
+		TargetListID string   `json:"target_list_id"`
+		Copy         bool     `json:"copy"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if len(input.ItemIDs) == 0 {
+		http.Error(w, "item_ids is required", http.StatusBadRequest)
+		return
+	}
+
+	items, ok := handleTransfer(w, listID, input.TargetListID, input.ItemIDs, input.Copy)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if input.Copy {
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(items)
+}
+
+// handleTransfer validates the target, runs transferItems and writes error responses
+func handleTransfer(w http.ResponseWriter, sourceID, targetID string, itemIDs []string, copyItems bool) ([]Item, bool) {
+	if targetID == "" {
+		http.Error(w, "target_list_id is required", http.StatusBadRequest)
+		return nil, false
+	}
+	if targetID == sourceID {
+		http.Error(w, "Target list must be different from the source list", http.StatusBadRequest)
+		return nil, false
+	}
+
+	items, err := transferItems(context.Background(), sourceID, targetID, itemIDs, copyItems)
+	switch {
+	case errors.Is(err, errTargetNotFound):
+		http.Error(w, "Target list not found", http.StatusNotFound)
+		return nil, false
+	case errors.Is(err, errItemsNotFound):
+		http.Error(w, "Item not found", http.StatusNotFound)
+		return nil, false
+	case err != nil:
+		http.Error(w, "Failed to move items", http.StatusInternalServerError)
+		return nil, false
+	}
+	return items, true
+}
+
+// transferItems moves (or copies) items from one list to the end of another in a single transaction
+// Both lists' item_history is updated in the same transaction: the target records an addition,
+// and a move takes the addition back from the source
+func transferItems(ctx context.Context, sourceID, targetID string, itemIDs []string, copyItems bool) ([]Item, error) {
+	uniqueIDs := []string{}
+	seen := map[string]bool{}
+	for _, id := range itemIDs {
+		if !seen[id] {
+			seen[id] = true
+			uniqueIDs = append(uniqueIDs, id)
+		}
+	}
+
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the target list so concurrent appends don't get the same sort_order
+	maxOrder, err := lockListForAppend(ctx, tx, targetID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, errTargetNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// All requested items must exist in the source list
+	// (counted up front: copies return new IDs, and moves return sub-items that weren't requested)
+	var found int
+	err = tx.QueryRow(ctx,
+		"SELECT COUNT(*) FROM items WHERE list_id = $1 AND id::text = ANY($2)",
+		sourceID, uniqueIDs).Scan(&found)
+	if err != nil {
+		return nil, err
+	}
+	if found != len(uniqueIDs) {
+		return nil, errItemsNotFound
+	}
+
+	// A sub-item that's moved without its parent is left behind by it, so the parent's checked state
+	// has to follow what's left
+	rows, err := tx.Query(ctx,
+		`SELECT DISTINCT parent_id::text FROM items
+		 WHERE list_id = $1 AND id::text = ANY($2) AND parent_id IS NOT NULL AND NOT parent_id::text = ANY($2)`,
+		sourceID, uniqueIDs)
+	if err != nil {
+		return nil, err
+	}
+	oldParents, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, err
+	}
+
+	// Copies are new rows, moves re-point the existing rows
+	// Either way the items are appended after the target's last item, in their source order,
+	// without a section (sections belong to the source list)
+	// A moved item takes its sub-items along; copies are made without sub-items and at the top level
+	var query string
+	if copyItems {
+		query = `INSERT INTO items (list_id, name, checked, sort_order)
+			SELECT $3, i.name, i.checked, $4 + row_number() OVER (ORDER BY ` + transferOrder + `)
+			FROM items i LEFT JOIN items p ON p.id = i.parent_id
+			WHERE i.list_id = $1 AND i.id::text = ANY($2)
+			RETURNING id, list_id, name, checked, sort_order, section_id::text, parent_id::text, created_at`
+	} else {
+		query = `UPDATE items SET list_id = $3, section_id = NULL, sort_order = $4 + s.rn,
+				parent_id = CASE WHEN items.parent_id::text = ANY($2) THEN items.parent_id END
+			FROM (
+				SELECT i.id, row_number() OVER (ORDER BY ` + transferOrder + `) AS rn
+				FROM items i LEFT JOIN items p ON p.id = i.parent_id
+				WHERE i.list_id = $1 AND (i.id::text = ANY($2) OR i.parent_id::text = ANY($2))
+			) s
+			WHERE items.id = s.id
+			RETURNING items.id, items.list_id, items.name, items.checked, items.sort_order,
+				items.section_id::text, items.parent_id::text, items.created_at`
+	}
+
+	rows, err = tx.Query(ctx, query, sourceID, uniqueIDs, targetID, maxOrder)
+	if err != nil {
+		return nil, err
+	}
+	var items []Item
+	for rows.Next() {
+		var item Item
+		err := rows.Scan(&item.ID, &item.ListID, &item.Name, &item.Checked,
+			&item.SortOrder, &item.SectionID, &item.ParentID, &item.CreatedAt)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, parentID := range oldParents {
+		if err := syncParentChecked(ctx, tx, &parentID); err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].SortOrder < items[j].SortOrder })
+
+	for _, item := range items {
+		if err := trackItemAddition(ctx, tx, targetID, item.Name); err != nil {
+			return nil, err
+		}
+		if !copyItems {
+			if err := untrackItemAddition(ctx, tx, sourceID, item.Name); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMoveRejectsBeforeWriting(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		itemID  string
+		body    string
+		want    string
+	}{
+		{"invalid JSON", MoveItem, "1", `{`, "Invalid JSON"},
+		{"no target", MoveItem, "1", `{"copy": true}`, "target_list_id is required"},
+		{"same list", MoveItem, "1", `{"target_list_id": "src"}`, "Target list must be different from the source list"},
+		{"no items", MoveItems, "", `{"target_list_id": "dst"}`, "item_ids is required"},
+		{"empty items", MoveItems, "", `{"item_ids": [], "target_list_id": "dst"}`, "item_ids is required"},
+		{"several to the same list", MoveItems, "", `{"item_ids": ["1", "2"], "target_list_id": "src"}`,
+			"Target list must be different from the source list"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/api/lists/src/items/move", strings.NewReader(tt.body))
+			r.SetPathValue("listId", "src")
+			r.SetPathValue("id", tt.itemID)
+			w := httptest.NewRecorder()
+			tt.handler(w, r)
+			if w.Code != http.StatusBadRequest || strings.TrimSpace(w.Body.String()) != tt.want {
+				t.Errorf("got %d %q, want 400 %q", w.Code, strings.TrimSpace(w.Body.String()), tt.want)
+			}
+		})
+	}
+}
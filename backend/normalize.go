@@ -0,0 +1,317 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Item names are compared by a normalized key, so "Milk", "milk " and "milks" are the same item
+// Per-list synonyms (see synonyms.go) map further keys onto each other, e.g. "milch" -> "milk"
+
+// irregularPlurals are plurals the suffix rules below get wrong
+var irregularPlurals = map[string]string{
+	"cookies":  "cookie",
+	"potatoes": "potato",
+	"tomatoes": "tomato",
+	"mangoes":  "mango",
+	"leaves":   "leaf",
+	"loaves":   "loaf",
+	"knives":   "knife",
+	"äpfel":    "apfel",
+	"eier":     "ei",
+	"nüsse":    "nuss",
+	"würste":   "wurst",
+}
+
+// pluralSuffixes are simple English and German plural endings, checked in order
+// The first matching rule wins; the resulting key doesn't have to be a real word,
+// it only has to be the same for the singular and the plural
+var pluralSuffixes = []struct {
+	suffix      string
+	replacement string
+}{
+	{"ies", "y"},   // berries, cherries
+	{"sses", "ss"}, // glasses
+	{"ches", "ch"}, // peaches
+	{"shes", "sh"}, // dishes
+	{"xes", "x"},   // boxes
+	{"eln", "el"},  // Nudeln, Zwiebeln, Kartoffeln
+	{"en", "e"},    // Tomaten, Bohnen, Zitronen
+	{"s", ""},      // eggs, apples, Joghurts
+}
+
+// Words shorter than this are never singularized ("gas", "eis")
+const minSingularLength = 4
+
+var foldCase = cases.Fold()
+
+// cleanItemName trims an item name and collapses inner whitespace, keeping its spelling
+func cleanItemName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// normalizeItemName returns the key used to compare item names:
+// Unicode NFKC, case-folded, whitespace collapsed and the last word singularized
+func normalizeItemName(name string) string {
+	words := strings.Fields(foldCase.String(norm.NFKC.String(name)))
+	if len(words) == 0 {
+		return ""
+	}
+	words[len(words)-1] = singularize(words[len(words)-1])
+	return strings.Join(words, " ")
+}
+
+// singularize strips a plural ending from a (case-folded) word
+// Rules are applied until nothing changes, so "chickens" and "chicken" end up with the same key
+func singularize(word string) string {
+	for range 3 {
+		next := singularizeOnce(word)
+		if next == word {
+			break
+		}
+		word = next
+	}
+	return word
+}
+
+func singularizeOnce(word string) string {
+	if singular, ok := irregularPlurals[word]; ok {
+		return singular
+	}
+	if len([]rune(word)) < minSingularLength {
+		return word
+	}
+	for _, rule := range pluralSuffixes {
+		if !strings.HasSuffix(word, rule.suffix) {
+			continue
+		}
+		// "ss", "us" and "is" endings aren't plurals (glass, hummus, Reis)
+		if rule.suffix == "s" && (strings.HasSuffix(word, "ss") ||
+			strings.HasSuffix(word, "us") || strings.HasSuffix(word, "is")) {
+			return word
+		}
+		return strings.TrimSuffix(word, rule.suffix) + rule.replacement
+	}
+	return word
+}
+
+// itemKey normalizes a name and resolves it through a list's synonyms
+func itemKey(name string, synonyms map[string]string) string {
+	key := normalizeItemName(name)
+	if canonical, ok := synonyms[key]; ok {
+		return canonical
+	}
+	return key
+}
+
+// listItemKeys returns the keys of all items currently on a list
+func listItemKeys(ctx context.Context, q querier, listID string, synonyms map[string]string) (map[string]bool, error) {
+	return queryItemKeys(ctx, q, "SELECT name FROM items WHERE list_id = $1", listID, synonyms)
+}
+
+// uncheckedItemKeys returns the keys of the items on a list that still have to be bought
+// Checked items can be suggested again: adding one unchecks it (see CreateItem)
+func uncheckedItemKeys(ctx context.Context, q querier, listID string, synonyms map[string]string) (map[string]bool, error) {
+	return queryItemKeys(ctx, q, "SELECT name FROM items WHERE list_id = $1 AND NOT checked", listID, synonyms)
+}
+
+func queryItemKeys(ctx context.Context, q querier, query, listID string, synonyms map[string]string) (map[string]bool, error) {
+	rows, err := q.Query(ctx, query, listID)
+	if err != nil {
+		return nil, err
+	}
+	names, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, err
+	}
+
+	keys := map[string]bool{}
+	for _, name := range names {
+		keys[itemKey(name, synonyms)] = true
+	}
+	return keys, nil
+}
+
+// findItemByKey returns the item on a list whose name has the given key, or nil if there is none
+// Only the sub-items of parentID are searched, or the top-level items if it's nil
+func findItemByKey(ctx context.Context, q querier, listID string, parentID *string, key string, synonyms map[string]string) (*Item, error) {
+	rows, err := q.Query(ctx,
+		`SELECT id, list_id, name, checked, sort_order, section_id::text, parent_id::text, created_at
+		 FROM items WHERE list_id = $1 AND parent_id::text IS NOT DISTINCT FROM $2
+		 ORDER BY sort_order ASC`, listID, parentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item Item
+		err := rows.Scan(&item.ID, &item.ListID, &item.Name, &item.Checked,
+			&item.SortOrder, &item.SectionID, &item.ParentID, &item.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if itemKey(item.Name, synonyms) == key {
+			return &item, nil
+		}
+	}
+	return nil, rows.Err()
+}
+
+// historyName returns the name an item is tracked under in item_history, and its key:
+// the existing record with the same key, or the cleaned-up name for a new record
+func historyName(ctx context.Context, q querier, listID, itemName string) (string, string, error) {
+	synonyms, err := loadSynonyms(ctx, q, listID)
+	if err != nil {
+		return "", "", err
+	}
+	key := itemKey(itemName, synonyms)
+
+	var name string
+	err = q.QueryRow(ctx,
+		`SELECT item_name FROM item_history WHERE list_id = $1 AND name_key = $2
+		 ORDER BY added_count DESC LIMIT 1`,
+		listID, key).Scan(&name)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return cleanItemName(itemName), key, nil
+	}
+	return name, key, err
+}
+
+// mergeHistoryGroup makes names[0] the item_history record for key and folds the others into it
+func mergeHistoryGroup(ctx context.Context, q querier, listID, key string, names []string) error {
+	_, err := q.Exec(ctx,
+		"UPDATE item_history SET name_key = $3 WHERE list_id = $1 AND item_name = $2",
+		listID, names[0], key)
+	if err != nil {
+		return err
+	}
+	for _, name := range names[1:] {
+		if err := mergeHistory(ctx, q, listID, names[0], name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mergeHistory folds the item_history record `from` into `into`
+// Counts are added up and the interval model of the record with more additions is kept.
+// Co-occurrence counts of `from` are left behind; they no longer match a record and are ignored
+func mergeHistory(ctx context.Context, q querier, listID, into, from string) error {
+	_, err := q.Exec(ctx,
+		`UPDATE item_history i
+		SET added_count = i.added_count + f.added_count,
+			last_added_at = GREATEST(i.last_added_at, f.last_added_at),
+			avg_days_between = CASE WHEN f.added_count > i.added_count THEN f.avg_days_between ELSE i.avg_days_between END,
+			interval_ewma = CASE WHEN f.added_count > i.added_count THEN f.interval_ewma ELSE i.interval_ewma END,
+			interval_variance = CASE WHEN f.added_count > i.added_count THEN f.interval_variance ELSE i.interval_variance END,
+			dismissed = i.dismissed AND f.dismissed,
+			dismissed_at = CASE WHEN i.dismissed AND f.dismissed THEN GREATEST(i.dismissed_at, f.dismissed_at) END,
+			snoozed_until = GREATEST(i.snoozed_until, f.snoozed_until)
+		FROM item_history f
+		WHERE i.list_id = $1 AND i.item_name = $2 AND f.list_id = $1 AND f.item_name = $3`,
+		listID, into, from)
+	if err != nil {
+		return err
+	}
+	_, err = q.Exec(ctx,
+		"UPDATE item_additions SET item_name = $2 WHERE list_id = $1 AND item_name = $3",
+		listID, into, from)
+	if err != nil {
+		return err
+	}
+	_, err = q.Exec(ctx,
+		"DELETE FROM item_history WHERE list_id = $1 AND item_name = $2",
+		listID, from)
+	return err
+}
+
+// runNormalizeHistory recomputes every item_history key with the current normalization and
+// synonyms and merges records that turn out to be the same item
+func runNormalizeHistory(args []string) error {
+	fs := flag.NewFlagSet("normalize-history", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	rows, err := DB.Query(ctx, "SELECT DISTINCT list_id FROM item_history")
+	if err != nil {
+		return err
+	}
+	listIDs, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return err
+	}
+
+	merged := 0
+	for _, listID := range listIDs {
+		n, err := normalizeListHistory(ctx, listID)
+		if err != nil {
+			return fmt.Errorf("list %s: %w", listID, err)
+		}
+		merged += n
+	}
+
+	fmt.Printf("Normalized %d lists, merged %d duplicate records\n", len(listIDs), merged)
+	return nil
+}
+
+// normalizeListHistory re-keys one list's item_history and returns the number of merged records
+func normalizeListHistory(ctx context.Context, listID string) (int, error) {
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	synonyms, err := loadSynonyms(ctx, tx, listID)
+	if err != nil {
+		return 0, err
+	}
+
+	// The most used spelling of an item survives a merge
+	rows, err := tx.Query(ctx,
+		`SELECT item_name FROM item_history WHERE list_id = $1
+		 ORDER BY added_count DESC, last_added_at DESC`, listID)
+	if err != nil {
+		return 0, err
+	}
+	names, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return 0, err
+	}
+
+	groups := map[string][]string{}
+	keys := []string{}
+	for _, name := range names {
+		key := itemKey(name, synonyms)
+		if groups[key] == nil {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], name)
+	}
+
+	// name_key is unique, so every record first gets a placeholder key (its ID) that
+	// can't collide with the new keys while they are assigned one group at a time
+	if _, err := tx.Exec(ctx,
+		"UPDATE item_history SET name_key = id::text WHERE list_id = $1", listID); err != nil {
+		return 0, err
+	}
+
+	merged := 0
+	for _, key := range keys {
+		if err := mergeHistoryGroup(ctx, tx, listID, key, groups[key]); err != nil {
+			return 0, err
+		}
+		merged += len(groups[key]) - 1
+	}
+	return merged, tx.Commit(ctx)
+}
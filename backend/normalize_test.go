@@ -0,0 +1,98 @@
+package main
+
+import "testing"
+
+func TestNormalizeItemName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"", ""},
+		{"   ", ""},
+		{"Milk", "milk"},
+		{"  milk  ", "milk"},
+		{"Oat   Milk", "oat milk"},
+		{"eggs", "egg"},
+		{"Eggs", "egg"},
+		{"berries", "berry"},
+		{"glasses", "glass"},
+		{"peaches", "peach"},
+		{"dishes", "dish"},
+		{"boxes", "box"},
+		{"Tomatoes", "tomato"},
+		{"cookies", "cookie"},
+		{"Nudeln", "nudel"},
+		{"Tomaten", "tomate"},
+		{"Äpfel", "apfel"},
+		{"Eier", "ei"},
+		{"STRASSE", "strasse"},
+		{"glass", "glass"},
+		{"hummus", "hummus"},
+		{"Reis", "reis"},
+		{"gas", "gas"},
+		{"peas", "pea"},
+		{"green beans", "green bean"},
+		{"ｍｉｌｋ", "milk"},
+	}
+	for _, tt := range tests {
+		if got := normalizeItemName(tt.name); got != tt.want {
+			t.Errorf("normalizeItemName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeItemNameSingularAndPlural(t *testing.T) {
+	pairs := [][2]string{
+		{"apple", "apples"},
+		{"chicken", "chickens"},
+		{"Zwiebel", "Zwiebeln"},
+		{"Kartoffel", "Kartoffeln"},
+		{"Zitrone", "Zitronen"},
+		{"potato", "potatoes"},
+		{"loaf", "loaves"},
+		{"Joghurt", "Joghurts"},
+	}
+	for _, p := range pairs {
+		if a, b := normalizeItemName(p[0]), normalizeItemName(p[1]); a != b {
+			t.Errorf("%q -> %q but %q -> %q", p[0], a, p[1], b)
+		}
+	}
+}
+
+func TestItemKey(t *testing.T) {
+	synonyms := map[string]string{"milch": "milk", "aubergine": "eggplant"}
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Milch", "milk"},
+		{"Milk", "milk"},
+		{"Aubergines", "eggplant"},
+		{"Bread", "bread"},
+	}
+	for _, tt := range tests {
+		if got := itemKey(tt.name, synonyms); got != tt.want {
+			t.Errorf("itemKey(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+	if got := itemKey("Milch", nil); got != "milch" {
+		t.Errorf("itemKey without synonyms = %q, want %q", got, "milch")
+	}
+}
+
+func TestCleanItemName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Milk", "Milk"},
+		{"  Oat   Milk ", "Oat Milk"},
+		{"\tEggs\n", "Eggs"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := cleanItemName(tt.name); got != tt.want {
+			t.Errorf("cleanItemName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"golang.org/x/text/language"
+)
+
+// Popularity model settings
+const (
+	// An item only becomes a global suggestion once this many different lists have added it,
+	// so nothing that only a handful of lists use is ever shown to others
+	popularityMinLists = 5
+	// Only established lists count: lists are free, so anyone could otherwise create a few lists
+	// with an item and watch whether it turns popular, learning whether some other list has it
+	popularityMinListAgeDays = 30
+	popularityMinActiveDays  = 5 // days on which the list had items added
+	popularityMinListItems   = 10
+	popularityRefreshEvery   = time.Hour
+	popularityCandidates     = 20
+	popularityPerListCap     = 10 // one list's additions count at most this often
+	coldStartEstablishedItem = 5  // lists with fewer items added 2+ times get popular suggestions
+	popularityWeight         = 0.6
+)
+
+// PopularItem is an item that many lists of a language use
+type PopularItem struct {
+	Key       string
+	Name      string
+	ListShare float64 // fraction of the language's lists that added it
+}
+
+// StartPopularityRefresher rebuilds the popularity aggregate in the background
+func StartPopularityRefresher() {
+	ticker := time.NewTicker(popularityRefreshEvery)
+	defer ticker.Stop()
+
+	for {
+		if err := refreshPopularity(context.Background()); err != nil {
+			log.Printf("Item popularity: %v", err)
+		}
+		<-ticker.C
+	}
+}
+
+// refreshPopularity recomputes item_popularity from the item_history of established lists
+// Only aggregates are stored: per language and item the number of lists, never which ones
+func refreshPopularity(ctx context.Context) error {
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, "DELETE FROM item_popularity"); err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx,
+		`WITH established AS (
+			SELECT l.id, l.language FROM lists l
+			WHERE l.language IS NOT NULL
+				AND l.created_at < NOW() - make_interval(days => $3)
+				AND (SELECT COUNT(DISTINCT a.added_at::date) FROM item_additions a WHERE a.list_id = l.id) >= $4
+				AND (SELECT COUNT(*) FROM item_history h WHERE h.list_id = l.id AND h.added_count > 0) >= $5
+		),
+		language_lists AS (
+			SELECT language, COUNT(*) AS lists FROM established GROUP BY language
+		)
+		INSERT INTO item_popularity (language, name_key, display_name, list_count, total_adds, language_lists)
+		SELECT l.language, h.name_key, mode() WITHIN GROUP (ORDER BY h.item_name),
+			COUNT(DISTINCT h.list_id), SUM(LEAST(h.added_count, $2)), MAX(ll.lists)
+		FROM item_history h
+		JOIN established l ON l.id = h.list_id
+		JOIN language_lists ll ON ll.language = l.language
+		WHERE h.added_count > 0
+		GROUP BY l.language, h.name_key
+		HAVING COUNT(DISTINCT h.list_id) >= $1`,
+		popularityMinLists, popularityPerListCap,
+		popularityMinListAgeDays, popularityMinActiveDays, popularityMinListItems)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// runRefreshPopularity is the refresh-popularity admin command
+func runRefreshPopularity(args []string) error {
+	if err := refreshPopularity(context.Background()); err != nil {
+		return err
+	}
+	var items int
+	DB.QueryRow(context.Background(), "SELECT COUNT(*) FROM item_popularity").Scan(&items)
+	fmt.Printf("Item popularity refreshed: %d items\n", items)
+	return nil
+}
+
+// loadPopularItems returns the most popular items in a language that a list has never added
+// An empty language falls back to the list's own language. Dismissed or snoozed ones are still returned
+// (the user can restore them); coldStartRecommender leaves them out
+func loadPopularItems(ctx context.Context, listID, lang string) ([]PopularItem, error) {
+	rows, err := DB.Query(ctx,
+		`SELECT p.name_key, p.display_name, p.list_count::float / GREATEST(p.language_lists, 1)
+		FROM item_popularity p
+		WHERE p.language = COALESCE(NULLIF($2, ''), (SELECT language FROM lists WHERE id = $1))
+			AND NOT EXISTS (SELECT 1 FROM item_history h
+				WHERE h.list_id = $1 AND h.name_key = p.name_key AND h.added_count > 0)
+		ORDER BY p.list_count DESC, p.total_adds DESC
+		LIMIT $3`,
+		listID, lang, popularityCandidates)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	popular := []PopularItem{}
+	for rows.Next() {
+		var p PopularItem
+		if err := rows.Scan(&p.Key, &p.Name, &p.ListShare); err != nil {
+			return nil, err
+		}
+		popular = append(popular, p)
+	}
+	return popular, rows.Err()
+}
+
+// coldStartRecommender adds globally popular items to another strategy's suggestions
+// while a list has little history of its own; the less history, the higher they rank.
+// Popular items the user dismissed or snoozed (in.Hidden) are left out
+type coldStartRecommender struct {
+	Recommender
+}
+
+func (s coldStartRecommender) Recommend(in RecommendationInput) []Recommendation {
+	recs := s.Recommender.Recommend(in)
+
+	established := 0
+	for _, h := range in.History {
+		if h.AddedCount >= 2 {
+			established++
+		}
+	}
+	if established >= coldStartEstablishedItem || len(in.Popular) == 0 {
+		return recs
+	}
+	weight := popularityWeight * (1 - float64(established)/coldStartEstablishedItem)
+
+	suggested := map[string]bool{}
+	for _, rec := range recs {
+		suggested[normalizeItemName(rec.Name)] = true
+	}
+	topShare := in.Popular[0].ListShare
+	for _, p := range in.Popular {
+		if suggested[p.Key] || in.OnList[p.Key] || in.Hidden[p.Key] || topShare == 0 {
+			continue
+		}
+		recs = append(recs, Recommendation{
+			Name:       p.Name,
+			Urgency:    weight * p.ListShare / topShare,
+			Confidence: p.ListShare,
+			Reason:     fmt.Sprintf("Popular: on %.0f%% of lists", p.ListShare*100),
+		})
+	}
+	return recs
+}
+
+// requestLanguage returns the primary language of the Accept-Language header ("de", "en"), or ""
+func requestLanguage(r *http.Request) string {
+	tags, _, err := language.ParseAcceptLanguage(r.Header.Get("Accept-Language"))
+	if err != nil || len(tags) == 0 {
+		return ""
+	}
+	// "*" parses as "mul" (multiple languages), which is no preference either
+	base, confidence := tags[0].Base()
+	if confidence == language.No || base.String() == "mul" {
+		return ""
+	}
+	return base.String()
+}
+
+// rememberListLanguage stores the language a list is used in, the first time it is known
+func rememberListLanguage(listID, lang string) {
+	if lang == "" {
+		return
+	}
+	DB.Exec(context.Background(),
+		"UPDATE lists SET language = $2 WHERE id = $1 AND language IS NULL", listID, lang)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"math"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// fixedRecommender returns the same suggestions for every input
+type fixedRecommender []Recommendation
+
+func (fixedRecommender) Name() string { return "fixed" }
+
+func (r fixedRecommender) Recommend(RecommendationInput) []Recommendation {
+	return append([]Recommendation{}, r...)
+}
+
+func TestColdStartRecommender(t *testing.T) {
+	popular := []PopularItem{
+		{Key: "milk", Name: "Milk", ListShare: 0.5},
+		{Key: "bread", Name: "Bread", ListShare: 0.25},
+		{Key: "egg", Name: "Eggs", ListShare: 0.2},
+	}
+	established := func(n int) []ItemHistory {
+		history := []ItemHistory{{ItemName: "once", AddedCount: 1}}
+		for range n {
+			history = append(history, ItemHistory{ItemName: "regular", AddedCount: 3})
+		}
+		return history
+	}
+
+	tests := []struct {
+		name    string
+		inner   fixedRecommender
+		history []ItemHistory
+		popular []PopularItem
+		onList  map[string]bool
+		hidden  map[string]bool
+		want    map[string]float64
+	}{
+		{"new list gets popular items", nil, established(0), popular, nil, nil,
+			map[string]float64{"Milk": popularityWeight, "Bread": popularityWeight / 2, "Eggs": popularityWeight * 0.4}},
+		{"weight shrinks with the list's own history", nil, established(2), popular, nil, nil,
+			map[string]float64{"Milk": popularityWeight * 0.6, "Bread": popularityWeight * 0.3, "Eggs": popularityWeight * 0.24}},
+		{"established list only gets its own suggestions", fixedRecommender{{Name: "Tea", Urgency: 1}},
+			established(coldStartEstablishedItem), popular, nil, nil, map[string]float64{"Tea": 1}},
+		{"no popularity aggregate", fixedRecommender{{Name: "Tea", Urgency: 1}}, nil, nil, nil, nil,
+			map[string]float64{"Tea": 1}},
+		{"items already suggested or on the list are skipped", fixedRecommender{{Name: "milk ", Urgency: 2}},
+			nil, popular, map[string]bool{"bread": true}, nil,
+			map[string]float64{"milk ": 2, "Eggs": popularityWeight * 0.4}},
+		{"dismissed or snoozed popular items are left out", nil, established(0), popular, nil,
+			map[string]bool{"bread": true, "egg": true},
+			map[string]float64{"Milk": popularityWeight}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := RecommendationInput{Now: time.Now(), History: tt.history, Popular: tt.popular,
+				OnList: tt.onList, Hidden: tt.hidden}
+			got := urgencies(coldStartRecommender{tt.inner}.Recommend(in))
+			if len(got) != len(tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+			for name, want := range tt.want {
+				if u, ok := got[name]; !ok || math.Abs(u-want) > 1e-9 {
+					t.Errorf("%s: urgency %v (suggested %t), want %v", name, u, ok, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRequestLanguage(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", ""},
+		{"de-DE,de;q=0.9,en;q=0.8", "de"},
+		{"en-US", "en"},
+		{"fr;q=0.5, it;q=0.9", "it"},
+		{"*", ""},
+		{"not a language!", ""},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept-Language", tt.header)
+		if got := requestLanguage(r); got != tt.want {
+			t.Errorf("requestLanguage(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"image"
+	"image/color"
+	"image/draw"
+	"net/http"
+	"strings"
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// Preview card layout (Open Graph recommends 1200x630)
+const (
+	previewWidth     = 1200
+	previewHeight    = 630
+	previewPadding   = 80
+	previewEmojiSize = 160
+	previewMaxItems  = 4 // unchecked items shown on the card, the rest are counted
+)
+
+var (
+	previewFontsOnce sync.Once
+	previewBold      *opentype.Font
+	previewRegular   *opentype.Font
+)
+
+// previewContent is what a list's preview card and share page show
+type previewContent struct {
+	Source iconSource
+	Lines  []string // item names and "+ N more", or only the item count for private lists
+	Count  bool     // Lines is the item count
+}
+
+// loadPreviewContent reads the list and its first unchecked items
+func loadPreviewContent(ctx context.Context, listID string) (previewContent, error) {
+	var content previewContent
+	src, err := loadIconSource(ctx, listID)
+	if err != nil {
+		return content, err
+	}
+	content.Source = src
+
+	var isPrivate bool
+	var total int
+	err = DB.QueryRow(ctx,
+		`SELECT l.is_private,
+		        (SELECT COUNT(*) FROM items i WHERE i.list_id = l.id AND NOT i.checked)
+		 FROM lists l WHERE l.id = $1`, listID).Scan(&isPrivate, &total)
+	if err != nil {
+		return content, err
+	}
+
+	if isPrivate || total == 0 {
+		content.Lines = []string{itemCount(total)}
+		content.Count = true
+		return content, nil
+	}
+
+	rows, err := DB.Query(ctx,
+		`SELECT i.name FROM items i `+itemTreeJoins+`
+		 WHERE i.list_id = $1 AND NOT i.checked
+		 ORDER BY `+itemTreeOrder+`
+		 LIMIT $2`, listID, previewMaxItems)
+	if err != nil {
+		return content, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return content, err
+		}
+		content.Lines = append(content.Lines, name)
+	}
+	if err := rows.Err(); err != nil {
+		return content, err
+	}
+	if more := total - len(content.Lines); more > 0 {
+		content.Lines = append(content.Lines, fmt.Sprintf("+ %d more", more))
+	}
+	return content, nil
+}
+
+// itemCount formats the number of items still to buy
+func itemCount(n int) string {
+	switch n {
+	case 0:
+		return "Nothing left to buy"
+	case 1:
+		return "1 item"
+	}
+	return fmt.Sprintf("%d items", n)
+}
+
+// GetListPreview handles GET /api/lists/{listId}/preview.png - the Open Graph card for shared links
+func GetListPreview(w http.ResponseWriter, r *http.Request) {
+	content, err := loadPreviewContent(context.Background(), r.PathValue("listId"))
+	if err != nil {
+		http.Error(w, "List not found", http.StatusNotFound)
+		return
+	}
+
+	key := content.Source.key(previewWidth, previewHeight, "preview")
+	key.Text = fmt.Sprintf("%s\n%t\n%s", content.Source.Name, content.Count, strings.Join(content.Lines, "\n"))
+	serveIcon(w, r, key, "image/png", func() ([]byte, error) {
+		return encodePNG(renderPreview(key, content))
+	})
+}
+
+// renderPreview draws the card: emoji and name at the top, the item lines below, all on the list's colour
+func renderPreview(key iconKey, content previewContent) *image.RGBA {
+	bgColor := parseHexColor(key.HexColor)
+	fg := contrastColor(bgColor)
+
+	img := image.NewRGBA(image.Rect(0, 0, previewWidth, previewHeight))
+	draw.Draw(img, img.Bounds(), &image.Uniform{bgColor}, image.Point{}, draw.Src)
+
+	// The emoji is an icon of the same colour, so it blends into the card
+	badge := key
+	badge.Width, badge.Height, badge.Variant = previewEmojiSize, previewEmojiSize, "any"
+	badgeRect := image.Rect(previewPadding, previewPadding, previewPadding+previewEmojiSize, previewPadding+previewEmojiSize)
+	draw.Draw(img, badgeRect, renderIcon(badge, 0.9), image.Point{}, draw.Over)
+
+	previewFontsOnce.Do(func() {
+		previewBold, _ = opentype.Parse(gobold.TTF)
+		previewRegular, _ = opentype.Parse(goregular.TTF)
+	})
+	if previewBold == nil || previewRegular == nil {
+		return img
+	}
+
+	nameX := badgeRect.Max.X + 40
+	drawTextLine(img, previewBold, 72, fg, content.Source.Name,
+		nameX, badgeRect.Min.Y+previewEmojiSize/2+26, previewWidth-previewPadding-nameX)
+
+	y := badgeRect.Max.Y + 60
+	for i, line := range content.Lines {
+		if !content.Count && i < previewMaxItems {
+			line = "• " + line
+		}
+		drawTextLine(img, previewRegular, 42, fg, line, previewPadding, y, previewWidth-2*previewPadding)
+		y += 54
+	}
+
+	muted := color.NRGBA{fg.R, fg.G, fg.B, 160}
+	drawTextLine(img, previewBold, 28, muted, "JORLIST", previewPadding, previewHeight-36, previewWidth)
+	return img
+}
+
+// drawTextLine draws text with its baseline at (x, y), shortened with "…" to fit maxWidth
+func drawTextLine(img *image.RGBA, f *opentype.Font, size float64, fg color.Color, text string, x, y, maxWidth int) {
+	face, err := opentype.NewFace(f, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		return
+	}
+	defer face.Close()
+
+	d := &font.Drawer{Dst: img, Src: image.NewUniform(fg), Face: face, Dot: fixed.P(x, y)}
+	limit := fixed.I(maxWidth)
+	if d.MeasureString(text) > limit {
+		runes := []rune(text)
+		for len(runes) > 0 && d.MeasureString(string(runes)+"…") > limit {
+			runes = runes[:len(runes)-1]
+		}
+		text = strings.TrimSpace(string(runes)) + "…"
+	}
+	d.DrawString(text)
+}
+
+// sharePage is a minimal page for link previews; browsers are sent on to the frontend
+var sharePage = template.Must(template.New("share").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<meta property="og:type" content="website">
+<meta property="og:site_name" content="JORLIST">
+<meta property="og:title" content="{{.Title}}">
+<meta property="og:description" content="{{.Description}}">
+<meta property="og:url" content="{{.URL}}">
+<meta property="og:image" content="{{.Image}}">
+<meta property="og:image:width" content="1200">
+<meta property="og:image:height" content="630">
+<meta name="twitter:card" content="summary_large_image">
+<meta name="twitter:title" content="{{.Title}}">
+<meta name="twitter:description" content="{{.Description}}">
+<meta name="twitter:image" content="{{.Image}}">
+<meta http-equiv="refresh" content="0; url={{.URL}}">
+</head>
+<body>
+<script>location.replace({{.URL}})</script>
+<a href="{{.URL}}">{{.Title}}</a>
+</body>
+</html>
+`))
+
+// ShareList handles GET /share/{listId} - Open Graph and Twitter Card tags for messengers,
+// then redirects to the list in the frontend
+func ShareList(w http.ResponseWriter, r *http.Request) {
+	listID := r.PathValue("listId")
+	content, err := loadPreviewContent(context.Background(), listID)
+	if err != nil {
+		http.Error(w, "List not found", http.StatusNotFound)
+		return
+	}
+
+	title := content.Source.Name
+	if content.Source.Emoji != "" {
+		title = content.Source.Emoji + " " + title
+	}
+	description := strings.Join(content.Lines, ", ")
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	sharePage.Execute(w, map[string]string{
+		"Title":       title,
+		"Description": description,
+		"URL":         fmt.Sprintf("%s/list/%s", frontendBaseURL(), listID),
+		"Image":       fmt.Sprintf("%s/api/lists/%s/preview.png", apiBaseURL(r), listID),
+	})
+}
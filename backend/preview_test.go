@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestItemCount(t *testing.T) {
+	tests := []struct {
+		n    int
+		want string
+	}{
+		{0, "Nothing left to buy"},
+		{1, "1 item"},
+		{2, "2 items"},
+		{120, "120 items"},
+	}
+	for _, tt := range tests {
+		if got := itemCount(tt.n); got != tt.want {
+			t.Errorf("itemCount(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestSharePageEscaping(t *testing.T) {
+	var buf bytes.Buffer
+	err := sharePage.Execute(&buf, map[string]string{
+		"Title":       `Party "</title><script>alert(1)</script>`,
+		"Description": `Chips, <b>Dip</b>`,
+		"URL":         "https://app.example.com/list/abc",
+		"Image":       "https://api.example.com/api/lists/abc/preview.png",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := buf.String()
+	if strings.Contains(page, "<script>alert") || strings.Contains(page, "<b>") {
+		t.Errorf("list content isn't escaped:\n%s", page)
+	}
+	for _, s := range []string{
+		`<meta property="og:url" content="https://app.example.com/list/abc">`,
+		`<meta http-equiv="refresh" content="0; url=https://app.example.com/list/abc">`,
+		`location.replace("https://app.example.com/list/abc")`,
+	} {
+		if !strings.Contains(page, s) {
+			t.Errorf("page doesn't contain %s:\n%s", s, page)
+		}
+	}
+}
+
+func TestRenderPreview(t *testing.T) {
+	content := previewContent{
+		Source: iconSource{Name: "A very long list name that certainly doesn't fit on the card", Emoji: "🛒", HexColor: "42b883"},
+		Lines:  []string{"Milk", "Bread", "+ 3 more"},
+	}
+	key := content.Source.key(previewWidth, previewHeight, "preview")
+	img := renderPreview(key, content)
+	if b := img.Bounds(); b.Dx() != previewWidth || b.Dy() != previewHeight {
+		t.Errorf("card is %dx%d, want %dx%d", b.Dx(), b.Dy(), previewWidth, previewHeight)
+	}
+	// Corners keep the list colour
+	if got := img.RGBAAt(previewWidth-1, 0); got != parseHexColor("42b883") {
+		t.Errorf("corner is %v", got)
+	}
+}
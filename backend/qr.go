@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"rsc.io/qr"
+)
+
+// QR code settings
+const (
+	qrQuietZone   = 4    // modules of white border required around the code
+	qrLogoShare   = 0.25 // logo width relative to the code: ~6% of its area, level H recovers up to 30%
+	qrDefaultSize = 512
+	// Dark modules need the WCAG contrast ratio for graphics against white, or black is used instead of the list colour
+	qrMinContrast = 3
+)
+
+// qrOptions are the query parameters of GetListQR
+type qrOptions struct {
+	Size  int  // PNG width in pixels
+	Color bool // dark modules in the list colour
+	Emoji bool // list icon in the centre
+}
+
+// parseQROptions reads ?size=, ?color= and ?emoji=
+func parseQROptions(r *http.Request) (qrOptions, error) {
+	opts := qrOptions{Size: qrDefaultSize}
+	query := r.URL.Query()
+	if s := query.Get("size"); s != "" {
+		size, err := strconv.Atoi(s)
+		if err != nil || size < 128 || size > 2048 {
+			return opts, errors.New("Invalid size (128-2048)")
+		}
+		opts.Size = size
+	}
+	for name, value := range map[string]*bool{"color": &opts.Color, "emoji": &opts.Emoji} {
+		if s := query.Get(name); s != "" {
+			b, err := strconv.ParseBool(s)
+			if err != nil {
+				return opts, fmt.Errorf("Invalid %s (true or false)", name)
+			}
+			*value = b
+		}
+	}
+	return opts, nil
+}
+
+// GetListQR handles GET /api/lists/{listId}/qr.png and qr.svg - a QR code of the list's link in the frontend
+// Optional: ?color=true draws it in the list colour, ?emoji=true puts the list icon in the centre, ?size= (PNG)
+func GetListQR(w http.ResponseWriter, r *http.Request) {
+	listID := r.PathValue("listId")
+	format := strings.TrimPrefix(path.Ext(r.URL.Path), ".")
+	opts, err := parseQROptions(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	src, err := loadIconSource(context.Background(), listID)
+	if err != nil {
+		http.Error(w, "List not found", http.StatusNotFound)
+		return
+	}
+
+	// Same URL as the manifest's start_url
+	link := fmt.Sprintf("%s/list/%s", frontendBaseURL(), listID)
+
+	// The logo covers modules, so use the highest error correction level whenever there is one
+	level := qr.M
+	if opts.Emoji {
+		level = qr.H
+	}
+	code, err := qr.Encode(link, level)
+	if err != nil {
+		http.Error(w, "Failed to encode QR code", http.StatusInternalServerError)
+		return
+	}
+
+	key := src.key(opts.Size, opts.Size, "qr")
+	key.Format = format
+	key.Text = fmt.Sprintf("%s\n%t\n%t", link, opts.Color, opts.Emoji)
+	if !opts.Emoji {
+		key.Content = ""
+	}
+	if !opts.Color {
+		key.HexColor = "000000"
+	}
+
+	if format == "svg" {
+		serveIcon(w, r, key, "image/svg+xml", func() ([]byte, error) {
+			return renderQRSVG(code, key, src.HexColor, opts), nil
+		})
+		return
+	}
+	serveIcon(w, r, key, "image/png", func() ([]byte, error) {
+		return encodePNG(renderQR(code, key, src.HexColor, opts))
+	})
+}
+
+// qrForeground is the colour of the dark modules
+func qrForeground(hexColor string, opts qrOptions) color.RGBA {
+	black := color.RGBA{0, 0, 0, 255}
+	if !opts.Color {
+		return black
+	}
+	fg := parseHexColor(hexColor)
+	if contrastRatio(fg, color.RGBA{255, 255, 255, 255}) < qrMinContrast {
+		return black
+	}
+	return fg
+}
+
+// qrLogoModules returns the logo's width and offset in modules
+// The width has the same parity as the code, so the logo is centred on whole modules
+func qrLogoModules(code *qr.Code) (int, int) {
+	width := int(float64(code.Size) * qrLogoShare)
+	if (code.Size-width)%2 != 0 {
+		width--
+	}
+	return width, (code.Size - width) / 2
+}
+
+// qrCleared reports whether a module lies under the logo, so it's left white
+func qrCleared(code *qr.Code, opts qrOptions, x, y int) bool {
+	if !opts.Emoji {
+		return false
+	}
+	width, offset := qrLogoModules(code)
+	return x >= offset && x < offset+width && y >= offset && y < offset+width
+}
+
+// renderQR draws the code as a PNG, with whole pixels per module
+func renderQR(code *qr.Code, key iconKey, hexColor string, opts qrOptions) *image.RGBA {
+	modules := code.Size + 2*qrQuietZone
+	scale := max(opts.Size/modules, 1)
+	margin := (opts.Size - modules*scale) / 2
+
+	img := image.NewRGBA(image.Rect(0, 0, opts.Size, opts.Size))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	fg := &image.Uniform{qrForeground(hexColor, opts)}
+
+	origin := margin + qrQuietZone*scale
+	for y := 0; y < code.Size; y++ {
+		for x := 0; x < code.Size; x++ {
+			if code.Black(x, y) && !qrCleared(code, opts, x, y) {
+				rect := image.Rect(origin+x*scale, origin+y*scale, origin+(x+1)*scale, origin+(y+1)*scale)
+				draw.Draw(img, rect, fg, image.Point{}, draw.Src)
+			}
+		}
+	}
+
+	if opts.Emoji {
+		// The list icon, inset by one module from the cleared area
+		width, offset := qrLogoModules(code)
+		logo := key
+		logo.HexColor = hexColor
+		logo.Width, logo.Height = (width-2)*scale, (width-2)*scale
+		pos := origin + (offset+1)*scale
+		draw.Draw(img, image.Rect(pos, pos, pos+logo.Width, pos+logo.Height),
+			renderIcon(logo, iconEmojiScale["any"]), image.Point{}, draw.Src)
+	}
+	return img
+}
+
+// renderQRSVG draws the code as SVG with one unit per module
+func renderQRSVG(code *qr.Code, key iconKey, hexColor string, opts qrOptions) []byte {
+	modules := code.Size + 2*qrQuietZone
+	fg := qrForeground(hexColor, opts)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, modules, modules)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="#ffffff"/>`, modules, modules)
+
+	// One path for all dark modules, drawn as horizontal runs
+	fmt.Fprintf(&buf, `<path fill="#%02x%02x%02x" d="`, fg.R, fg.G, fg.B)
+	for y := 0; y < code.Size; y++ {
+		for x := 0; x < code.Size; {
+			if !code.Black(x, y) || qrCleared(code, opts, x, y) {
+				x++
+				continue
+			}
+			start := x
+			for x < code.Size && code.Black(x, y) && !qrCleared(code, opts, x, y) {
+				x++
+			}
+			fmt.Fprintf(&buf, "M%d %dh%dv1h-%dz", start+qrQuietZone, y+qrQuietZone, x-start, x-start)
+		}
+	}
+	buf.WriteString(`"/>`)
+
+	if opts.Emoji {
+		width, offset := qrLogoModules(code)
+		logo := key
+		logo.HexColor = hexColor
+		logo.Width, logo.Height, logo.Variant = width-2, width-2, "rounded"
+		icon := renderIconSVG(logo, iconEmojiScale["rounded"])
+		pos := offset + 1 + qrQuietZone
+		attrs := fmt.Sprintf(`<svg x="%d" y="%d" `, pos, pos)
+		buf.Write(bytes.Replace(icon, []byte("<svg "), []byte(attrs), 1))
+	}
+	buf.WriteString(`</svg>`)
+	return buf.Bytes()
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"image/color"
+	"net/http/httptest"
+	"testing"
+
+	"rsc.io/qr"
+)
+
+func TestQRLogoModules(t *testing.T) {
+	tests := []struct {
+		size          int
+		width, offset int
+	}{
+		{21, 5, 8},
+		{25, 5, 10},
+		{29, 7, 11},
+		{33, 7, 13},
+		{57, 13, 22},
+		{177, 43, 67},
+	}
+	for _, tt := range tests {
+		code := &qr.Code{Size: tt.size}
+		width, offset := qrLogoModules(code)
+		if width != tt.width || offset != tt.offset {
+			t.Errorf("size %d: width %d at %d, want %d at %d", tt.size, width, offset, tt.width, tt.offset)
+		}
+	}
+}
+
+func TestQRLogoModulesCentred(t *testing.T) {
+	// Every QR version: the logo sits on whole modules in the middle and covers at most qrLogoShare² of the code
+	for version := 1; version <= 40; version++ {
+		size := 17 + 4*version
+		width, offset := qrLogoModules(&qr.Code{Size: size})
+		if 2*offset+width != size {
+			t.Errorf("size %d: logo of %d at %d isn't centred", size, width, offset)
+		}
+		if share := float64(width*width) / float64(size*size); share > qrLogoShare*qrLogoShare {
+			t.Errorf("size %d: logo covers %.3f of the code", size, share)
+		}
+	}
+}
+
+func TestQRCleared(t *testing.T) {
+	code := &qr.Code{Size: 21} // logo is 5 modules at offset 8
+	tests := []struct {
+		emoji bool
+		x, y  int
+		want  bool
+	}{
+		{true, 8, 8, true},
+		{true, 12, 12, true},
+		{true, 10, 10, true},
+		{true, 7, 10, false},
+		{true, 13, 10, false},
+		{true, 10, 13, false},
+		{false, 10, 10, false},
+	}
+	for _, tt := range tests {
+		if got := qrCleared(code, qrOptions{Emoji: tt.emoji}, tt.x, tt.y); got != tt.want {
+			t.Errorf("qrCleared(emoji=%t, %d, %d) = %t, want %t", tt.emoji, tt.x, tt.y, got, tt.want)
+		}
+	}
+}
+
+func TestQRForeground(t *testing.T) {
+	black := color.RGBA{0, 0, 0, 255}
+	tests := []struct {
+		hex   string
+		color bool
+		want  color.RGBA
+	}{
+		{"1e3a8a", false, black},
+		{"1e3a8a", true, color.RGBA{0x1e, 0x3a, 0x8a, 255}},
+		{"ffeb3b", true, black}, // too light to scan
+	}
+	for _, tt := range tests {
+		if got := qrForeground(tt.hex, qrOptions{Color: tt.color}); got != tt.want {
+			t.Errorf("qrForeground(%q, color=%t) = %v, want %v", tt.hex, tt.color, got, tt.want)
+		}
+	}
+}
+
+func TestParseQROptions(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    qrOptions
+		wantErr bool
+	}{
+		{"", qrOptions{Size: qrDefaultSize}, false},
+		{"size=256&color=true&emoji=1", qrOptions{Size: 256, Color: true, Emoji: true}, false},
+		{"emoji=false", qrOptions{Size: qrDefaultSize}, false},
+		{"size=64", qrOptions{}, true},
+		{"size=4096", qrOptions{}, true},
+		{"size=big", qrOptions{}, true},
+		{"color=yes", qrOptions{}, true},
+	}
+	for _, tt := range tests {
+		got, err := parseQROptions(httptest.NewRequest("GET", "/api/lists/1/qr.png?"+tt.query, nil))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseQROptions(%q) error = %v, want error %t", tt.query, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("parseQROptions(%q) = %+v, want %+v", tt.query, got, tt.want)
+		}
+	}
+}
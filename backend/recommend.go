@@ -0,0 +1,286 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Number of suggestions returned by GetRecommendations
+const maxRecommendations = 10
+
+// RecommendationInput is everything a strategy may look at when scoring a list
+// History only contains candidates: items that aren't dismissed or snoozed and aren't on the list unchecked
+// Popular holds items other lists of the same language use (see popularity.go), OnList the keys of unchecked items
+// and Hidden the keys the user dismissed or snoozed.
+// Now is in the list's time zone, Seasonality maps item names to their weekday/month pattern (see seasonal.go)
+type RecommendationInput struct {
+	ListID      string
+	History     []ItemHistory
+	Now         time.Time
+	Popular     []PopularItem
+	OnList      map[string]bool
+	Hidden      map[string]bool
+	Seasonality map[string]*seasonality
+}
+
+// Recommender is a pluggable suggestion strategy
+// Recommend returns scored candidates; sorting and the result limit are applied by the caller
+type Recommender interface {
+	Name() string
+	Recommend(in RecommendationInput) []Recommendation
+}
+
+// recommenders are the strategies that can be selected with ?strategy= or the RECOMMENDER env var
+// and that the eval-recommendations command compares
+var recommenders = map[string]Recommender{
+	"interval":  intervalRecommender{minAdds: 2, minUrgency: 0.5},
+	"frequency": frequencyRecommender{minAdds: 2, limit: maxRecommendations},
+	"seasonal": seasonalRecommender{
+		Recommender: intervalRecommender{minAdds: 2, minUrgency: 0.25},
+		minUrgency:  0.5,
+	},
+	"blend": blendRecommender{
+		name: "blend",
+		parts: []weightedRecommender{
+			{intervalRecommender{minAdds: 2, minUrgency: 0.5}, 0.8},
+			{frequencyRecommender{minAdds: 2, limit: maxRecommendations}, 0.2},
+		},
+	},
+}
+
+// defaultRecommender returns the strategy used when the request doesn't pick one
+// Seasonal is the interval heuristic scaled by when the list is used; without a pattern it ranks the same
+func defaultRecommender() Recommender {
+	if r, ok := recommenders[os.Getenv("RECOMMENDER")]; ok {
+		return r
+	}
+	return recommenders["seasonal"]
+}
+
+// intervalRecommender is the original heuristic:
+// urgency = days since last addition / expected days between additions (EWMA, see intervals.go)
+type intervalRecommender struct {
+	minAdds    int     // ignore items added fewer times than this
+	minUrgency float64 // only suggest items with decent urgency
+}
+
+func (intervalRecommender) Name() string { return "interval" }
+
+func (s intervalRecommender) Recommend(in RecommendationInput) []Recommendation {
+	recs := []Recommendation{}
+	for _, h := range in.History {
+		if h.AddedCount < s.minAdds {
+			continue
+		}
+		daysSince := in.Now.Sub(h.LastAddedAt).Hours() / 24
+		urgency := 0.5
+		reason := fmt.Sprintf("Added %d times; last added %s", h.AddedCount, formatDaysAgo(daysSince))
+		if interval := expectedInterval(h); interval > 0 {
+			urgency = daysSince / interval
+			reason = fmt.Sprintf("You usually buy this every %s; last added %s",
+				formatDays(interval), formatDaysAgo(daysSince))
+		}
+		if urgency >= s.minUrgency {
+			recs = append(recs, Recommendation{
+				Name:       h.ItemName,
+				Urgency:    urgency,
+				Confidence: intervalConfidence(h),
+				Reason:     reason,
+			})
+		}
+	}
+	return recs
+}
+
+// frequencyRecommender is a baseline that suggests the most frequently added items,
+// scaled so the most frequent one has urgency 1
+type frequencyRecommender struct {
+	minAdds int
+	limit   int
+}
+
+func (frequencyRecommender) Name() string { return "frequency" }
+
+func (s frequencyRecommender) Recommend(in RecommendationInput) []Recommendation {
+	maxCount := 0
+	for _, h := range in.History {
+		maxCount = max(maxCount, h.AddedCount)
+	}
+
+	recs := []Recommendation{}
+	for _, h := range in.History {
+		if h.AddedCount < s.minAdds {
+			continue
+		}
+		recs = append(recs, Recommendation{
+			Name:       h.ItemName,
+			Urgency:    float64(h.AddedCount) / float64(maxCount),
+			Confidence: intervalConfidence(h),
+			Reason:     fmt.Sprintf("One of your most frequent items (added %d times)", h.AddedCount),
+		})
+	}
+	return topRecommendations(recs, s.limit)
+}
+
+// weightedRecommender is one part of a blend
+type weightedRecommender struct {
+	Recommender
+	weight float64
+}
+
+// blendRecommender combines strategies by a weighted sum of their urgencies
+type blendRecommender struct {
+	name  string
+	parts []weightedRecommender
+}
+
+func (s blendRecommender) Name() string { return s.name }
+
+func (s blendRecommender) Recommend(in RecommendationInput) []Recommendation {
+	// Urgencies are summed, the confidence is the highest any part has
+	// and the reason comes from the part that contributed most
+	combined := map[string]*Recommendation{}
+	topContribution := map[string]float64{}
+	order := []string{}
+	for _, part := range s.parts {
+		for _, rec := range part.Recommend(in) {
+			c, seen := combined[rec.Name]
+			if !seen {
+				c = &Recommendation{Name: rec.Name}
+				combined[rec.Name] = c
+				order = append(order, rec.Name)
+			}
+			contribution := rec.Urgency * part.weight
+			c.Urgency += contribution
+			c.Confidence = max(c.Confidence, rec.Confidence)
+			if !seen || contribution > topContribution[rec.Name] {
+				c.Reason = rec.Reason
+				topContribution[rec.Name] = contribution
+			}
+		}
+	}
+
+	recs := make([]Recommendation, 0, len(order))
+	for _, name := range order {
+		recs = append(recs, *combined[name])
+	}
+	return recs
+}
+
+// formatDays formats a number of days for a recommendation reason, e.g. "6 days"
+func formatDays(days float64) string {
+	n := int(math.Round(days))
+	if n <= 1 {
+		return "day"
+	}
+	return fmt.Sprintf("%d days", n)
+}
+
+// formatDaysAgo formats the time since an addition, e.g. "9 days ago"
+func formatDaysAgo(days float64) string {
+	switch n := int(math.Round(days)); n {
+	case 0:
+		return "today"
+	case 1:
+		return "yesterday"
+	default:
+		return fmt.Sprintf("%d days ago", n)
+	}
+}
+
+// topRecommendations sorts by urgency (highest first) and keeps at most limit entries
+func topRecommendations(recs []Recommendation, limit int) []Recommendation {
+	sort.SliceStable(recs, func(i, j int) bool { return recs[i].Urgency > recs[j].Urgency })
+	if len(recs) > limit {
+		recs = recs[:limit]
+	}
+	return recs
+}
+
+// loadRecommendationInput fetches the candidates for a list:
+// history entries that aren't dismissed or snoozed and whose item isn't on the list unchecked (by normalized name),
+// plus popular items in the given language and each item's seasonality in the given time zone
+func loadRecommendationInput(ctx context.Context, listID, lang string, loc *time.Location) (RecommendationInput, error) {
+	in := RecommendationInput{ListID: listID, Now: time.Now().In(loc), History: []ItemHistory{}}
+
+	synonyms, err := loadSynonyms(ctx, DB, listID)
+	if err != nil {
+		return in, err
+	}
+	in.OnList, err = uncheckedItemKeys(ctx, DB, listID, synonyms)
+	if err != nil {
+		return in, err
+	}
+
+	rows, err := DB.Query(ctx,
+		`SELECT `+historyColumns+`
+		FROM item_history
+		WHERE list_id = $1 AND dismissed = false
+			AND (snoozed_until IS NULL OR snoozed_until <= NOW())`, listID)
+	if err != nil {
+		return in, err
+	}
+	history, err := collectHistory(rows)
+	if err != nil {
+		return in, err
+	}
+	for _, h := range history {
+		if !in.OnList[h.NameKey] {
+			in.History = append(in.History, h)
+		}
+	}
+
+	// Popular suggestions come from other lists, so they are hidden by key
+	rows, err = DB.Query(ctx,
+		`SELECT name_key FROM item_history
+		WHERE list_id = $1 AND (dismissed OR snoozed_until > NOW())`, listID)
+	if err != nil {
+		return in, err
+	}
+	hidden, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return in, err
+	}
+	in.Hidden = map[string]bool{}
+	for _, key := range hidden {
+		in.Hidden[key] = true
+	}
+
+	in.Seasonality, err = loadSeasonality(ctx, listID, loc)
+	if err != nil {
+		return in, err
+	}
+
+	// Popular items are optional - without the aggregate there are just no cold-start suggestions
+	if popular, err := loadPopularItems(ctx, listID, lang); err == nil {
+		in.Popular = popular
+	}
+	return in, nil
+}
+
+// historyColumns is the column list that collectHistory scans
+const historyColumns = `id::text, list_id, item_name, name_key, added_count, last_added_at,
+	avg_days_between, interval_ewma, interval_variance, dismissed`
+
+// collectHistory scans rows selected with historyColumns
+func collectHistory(rows pgx.Rows) ([]ItemHistory, error) {
+	defer rows.Close()
+
+	history := []ItemHistory{}
+	for rows.Next() {
+		var h ItemHistory
+		err := rows.Scan(&h.ID, &h.ListID, &h.ItemName, &h.NameKey, &h.AddedCount, &h.LastAddedAt,
+			&h.AvgDaysBetween, &h.IntervalEWMA, &h.IntervalVariance, &h.Dismissed)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, h)
+	}
+	return history, rows.Err()
+}
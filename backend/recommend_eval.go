@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+// evalResult accumulates hits for one strategy across all lists
+type evalResult struct {
+	recommended int // suggestions made
+	relevant    int // items that were actually added during the window
+	hits        int // suggestions that were actually added
+}
+
+// runEvalRecommendations replays the addition timeline up to `window` days ago, asks every strategy
+// for its top-k suggestions at that point, and checks them against what was actually added since
+func runEvalRecommendations(args []string) error {
+	fs := flag.NewFlagSet("eval-recommendations", flag.ContinueOnError)
+	window := fs.Int("window", 14, "evaluation window in days")
+	k := fs.Int("k", maxRecommendations, "number of suggestions per list")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	history, err := loadAllHistory(context.Background())
+	if err != nil {
+		return err
+	}
+	additions, err := loadAllAdditions(context.Background())
+	if err != nil {
+		return err
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -*window)
+	results := map[string]*evalResult{}
+	for name := range recommenders {
+		results[name] = &evalResult{}
+	}
+
+	lists := 0
+	for listID, entries := range history {
+		in, relevant := replayHistory(listID, entries, additions[listID], cutoff)
+		if len(relevant) == 0 {
+			continue
+		}
+		lists++
+
+		for name, recommender := range recommenders {
+			res := results[name]
+			res.relevant += len(relevant)
+			for _, rec := range topRecommendations(recommender.Recommend(in), *k) {
+				res.recommended++
+				if relevant[rec.Name] {
+					res.hits++
+				}
+			}
+		}
+	}
+
+	fmt.Printf("Replayed %d lists as of %s (window %d days, k=%d)\n\n",
+		lists, cutoff.Format("2006-01-02"), *window, *k)
+
+	names := make([]string, 0, len(results))
+	for name := range results {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "strategy\tsuggested\thits\tprecision\trecall")
+	for _, name := range names {
+		res := results[name]
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.3f\t%.3f\n", name, res.recommended, res.hits,
+			ratio(res.hits, res.recommended), ratio(res.hits, res.relevant))
+	}
+	return tw.Flush()
+}
+
+// replayHistory reconstructs a list's candidates as they looked at the cutoff
+// Items with a complete addition timeline are replayed exactly; for older items (added before the
+// timeline was recorded) the aggregate is rolled back by one interval instead.
+// Returns the candidates and the set of known items that were added again after the cutoff
+func replayHistory(listID string, entries []ItemHistory, additions map[string][]time.Time, cutoff time.Time) (RecommendationInput, map[string]bool) {
+	in := RecommendationInput{ListID: listID, Now: cutoff, History: []ItemHistory{},
+		Seasonality: map[string]*seasonality{}}
+	relevant := map[string]bool{}
+
+	// The timeline starts with the list's first recorded addition
+	since := cutoff
+	for _, times := range additions {
+		for _, at := range times {
+			if at.Before(since) {
+				since = at
+			}
+		}
+	}
+
+	for _, h := range entries {
+		times := additions[h.ItemName]
+		season := &seasonality{since: since}
+		for _, at := range times {
+			if !at.After(cutoff) {
+				season.add(at)
+			}
+		}
+		in.Seasonality[h.ItemName] = season
+
+		if len(times) >= h.AddedCount {
+			replayed := ItemHistory{ID: h.ID, ListID: h.ListID, ItemName: h.ItemName}
+			addedAfter := false
+			for _, at := range times {
+				if at.After(cutoff) {
+					addedAfter = true
+					continue
+				}
+				applyAddition(&replayed, at)
+			}
+			// Items first added after the cutoff can't be predicted from history
+			if replayed.AddedCount == 0 {
+				continue
+			}
+			if addedAfter {
+				relevant[h.ItemName] = true
+			}
+			in.History = append(in.History, replayed)
+			continue
+		}
+
+		if h.LastAddedAt.After(cutoff) {
+			if h.AddedCount < 2 {
+				continue
+			}
+			h.AddedCount--
+			h.LastAddedAt = h.LastAddedAt.Add(-time.Duration(expectedInterval(h) * 24 * float64(time.Hour)))
+			if h.LastAddedAt.After(cutoff) {
+				continue
+			}
+			relevant[h.ItemName] = true
+		}
+		in.History = append(in.History, h)
+	}
+	return in, relevant
+}
+
+// loadAllHistory returns every list's item_history, grouped by list ID
+func loadAllHistory(ctx context.Context) (map[string][]ItemHistory, error) {
+	rows, err := DB.Query(ctx, "SELECT "+historyColumns+" FROM item_history")
+	if err != nil {
+		return nil, err
+	}
+	entries, err := collectHistory(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	history := map[string][]ItemHistory{}
+	for _, h := range entries {
+		history[h.ListID] = append(history[h.ListID], h)
+	}
+	return history, nil
+}
+
+// loadAllAdditions returns every list's addition timeline: list ID -> item name -> times (oldest first)
+func loadAllAdditions(ctx context.Context) (map[string]map[string][]time.Time, error) {
+	rows, err := DB.Query(ctx,
+		"SELECT list_id, item_name, added_at FROM item_additions ORDER BY added_at ASC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	additions := map[string]map[string][]time.Time{}
+	for rows.Next() {
+		var listID, name string
+		var at time.Time
+		if err := rows.Scan(&listID, &name, &at); err != nil {
+			return nil, err
+		}
+		if additions[listID] == nil {
+			additions[listID] = map[string][]time.Time{}
+		}
+		additions[listID][name] = append(additions[listID][name], at)
+	}
+	return additions, rows.Err()
+}
+
+// ratio returns a/b, or 0 if b is 0
+func ratio(a, b int) float64 {
+	if b == 0 {
+		return 0
+	}
+	return float64(a) / float64(b)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestReplayHistory(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	day := func(d int) time.Time { return start.AddDate(0, 0, d) }
+	f := func(v float64) *float64 { return &v }
+	cutoff := day(15)
+
+	entries := []ItemHistory{
+		{ItemName: "Milk", AddedCount: 4, LastAddedAt: day(21)},                                          // full timeline
+		{ItemName: "Cake", AddedCount: 1, LastAddedAt: day(20)},                                          // first added after the cutoff
+		{ItemName: "Salt", AddedCount: 3, LastAddedAt: day(10), AvgDaysBetween: f(30)},                   // no timeline, not added since
+		{ItemName: "Rice", AddedCount: 4, LastAddedAt: day(18), AvgDaysBetween: f(7)},                    // no timeline, rolled back
+		{ItemName: "Tea", AddedCount: 1, LastAddedAt: day(18)},                                           // no timeline, nothing to roll back to
+		{ItemName: "Jam", AddedCount: 3, LastAddedAt: day(20), IntervalEWMA: f(2), AvgDaysBetween: f(2)}, // still after the cutoff
+	}
+	additions := map[string][]time.Time{
+		"Milk": {day(0), day(7), day(14), day(21)},
+		"Cake": {day(20)},
+	}
+
+	in, relevant := replayHistory("list", entries, additions, cutoff)
+
+	want := map[string]struct {
+		count int
+		last  time.Time
+	}{
+		"Milk": {3, day(14)},
+		"Salt": {3, day(10)},
+		"Rice": {3, day(11)},
+	}
+	if len(in.History) != len(want) {
+		t.Errorf("%d candidates, want %d", len(in.History), len(want))
+	}
+	for _, h := range in.History {
+		w, ok := want[h.ItemName]
+		if !ok {
+			t.Errorf("unexpected candidate %s", h.ItemName)
+			continue
+		}
+		if h.AddedCount != w.count || !h.LastAddedAt.Equal(w.last) {
+			t.Errorf("%s: added %d times, last %s, want %d and %s", h.ItemName, h.AddedCount, h.LastAddedAt, w.count, w.last)
+		}
+		if h.LastAddedAt.After(cutoff) {
+			t.Errorf("%s leaks an addition after the cutoff", h.ItemName)
+		}
+	}
+	for _, h := range in.History {
+		if h.ItemName == "Milk" && (h.IntervalEWMA == nil || math.Abs(*h.IntervalEWMA-7) > 1e-9) {
+			t.Errorf("Milk: replayed interval %v, want 7", valueOr(h.IntervalEWMA, -1))
+		}
+	}
+
+	if !relevant["Milk"] || !relevant["Rice"] || len(relevant) != 2 {
+		t.Errorf("relevant = %v, want Milk and Rice", relevant)
+	}
+	if !in.Now.Equal(cutoff) {
+		t.Errorf("Now = %s, want the cutoff", in.Now)
+	}
+	if s := in.Seasonality["Milk"]; s == nil || s.total != 3 || !s.since.Equal(day(0)) {
+		t.Errorf("Milk seasonality = %+v, want 3 additions since the first one", s)
+	}
+}
+
+func TestRatio(t *testing.T) {
+	tests := []struct {
+		a, b int
+		want float64
+	}{
+		{1, 2, 0.5},
+		{3, 3, 1},
+		{0, 5, 0},
+		{4, 0, 0},
+	}
+	for _, tt := range tests {
+		if got := ratio(tt.a, tt.b); got != tt.want {
+			t.Errorf("ratio(%d, %d) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
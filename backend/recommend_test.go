@@ -0,0 +1,157 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// testHistory is a list with a regular item, a rarely added one, one that isn't due and one without an interval
+func testHistory(now time.Time) []ItemHistory {
+	days := func(d float64) time.Time { return now.Add(-time.Duration(d * 24 * float64(time.Hour))) }
+	f := func(v float64) *float64 { return &v }
+	return []ItemHistory{
+		{ItemName: "Milk", AddedCount: 5, LastAddedAt: days(7), AvgDaysBetween: f(7), IntervalEWMA: f(7), IntervalVariance: f(0)},
+		{ItemName: "Bread", AddedCount: 1, LastAddedAt: days(20)},
+		{ItemName: "Eggs", AddedCount: 3, LastAddedAt: days(2), AvgDaysBetween: f(10), IntervalEWMA: f(10), IntervalVariance: f(1)},
+		{ItemName: "Salt", AddedCount: 2, LastAddedAt: days(30)},
+	}
+}
+
+// urgencies maps recommendation names to their urgency
+func urgencies(recs []Recommendation) map[string]float64 {
+	m := map[string]float64{}
+	for _, rec := range recs {
+		m[rec.Name] = rec.Urgency
+	}
+	return m
+}
+
+func TestRecommenders(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	in := RecommendationInput{ListID: "list", Now: now, History: testHistory(now)}
+
+	tests := []struct {
+		strategy string
+		want     map[string]float64
+	}{
+		// Milk is due (7 of 7 days), Salt has no interval (0.5), Eggs aren't due (2 of 10 days)
+		{"interval", map[string]float64{"Milk": 1, "Salt": 0.5}},
+		// Scaled by the most frequent item; Bread was only added once
+		{"frequency", map[string]float64{"Milk": 1, "Eggs": 0.6, "Salt": 0.4}},
+		{"blend", map[string]float64{"Milk": 0.8 + 0.2, "Eggs": 0.2 * 0.6, "Salt": 0.8*0.5 + 0.2*0.4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			r := recommenders[tt.strategy]
+			if r.Name() != tt.strategy {
+				t.Errorf("Name() = %q", r.Name())
+			}
+			got := urgencies(r.Recommend(in))
+			if len(got) != len(tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+			for name, want := range tt.want {
+				if u, ok := got[name]; !ok || math.Abs(u-want) > 1e-9 {
+					t.Errorf("%s: urgency %v (suggested %t), want %v", name, u, ok, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRecommendersWithoutHistory(t *testing.T) {
+	in := RecommendationInput{ListID: "list", Now: time.Now(), History: []ItemHistory{}}
+	for name, r := range recommenders {
+		if recs := r.Recommend(in); len(recs) != 0 {
+			t.Errorf("%s suggested %v for an empty history", name, recs)
+		}
+	}
+}
+
+func TestTopRecommendations(t *testing.T) {
+	recs := []Recommendation{{Name: "a", Urgency: 0.5}, {Name: "b", Urgency: 2}, {Name: "c", Urgency: 1}, {Name: "d", Urgency: 1}}
+	got := topRecommendations(recs, 3)
+	want := []string{"b", "c", "d"} // ties keep their order
+	if len(got) != len(want) {
+		t.Fatalf("got %d recommendations, want %d", len(got), len(want))
+	}
+	for i, name := range want {
+		if got[i].Name != name {
+			t.Errorf("position %d: %s, want %s", i, got[i].Name, name)
+		}
+	}
+	if got := topRecommendations(nil, 3); len(got) != 0 {
+		t.Errorf("got %v for no recommendations", got)
+	}
+}
+
+func TestDefaultRecommender(t *testing.T) {
+	tests := []struct {
+		env  string
+		want string
+	}{
+		{"", "seasonal"},
+		{"frequency", "frequency"},
+		{"interval", "interval"},
+		{"unknown", "seasonal"},
+	}
+	for _, tt := range tests {
+		t.Setenv("RECOMMENDER", tt.env)
+		if got := defaultRecommender().Name(); got != tt.want {
+			t.Errorf("RECOMMENDER=%q: %s, want %s", tt.env, got, tt.want)
+		}
+	}
+}
+
+func TestRecommendationReasons(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	in := RecommendationInput{ListID: "list", Now: now, History: testHistory(now)}
+	tests := []struct {
+		strategy, item, want string
+	}{
+		{"interval", "Milk", "You usually buy this every 7 days; last added 7 days ago"},
+		{"interval", "Salt", "Added 2 times; last added 30 days ago"},
+		{"frequency", "Eggs", "One of your most frequent items (added 3 times)"},
+		// A blend explains an item with the part that contributed most
+		{"blend", "Milk", "You usually buy this every 7 days; last added 7 days ago"},
+		{"blend", "Eggs", "One of your most frequent items (added 3 times)"},
+	}
+	for _, tt := range tests {
+		found := false
+		for _, rec := range recommenders[tt.strategy].Recommend(in) {
+			if rec.Name == tt.item {
+				found = true
+				if rec.Reason != tt.want {
+					t.Errorf("%s/%s: reason %q, want %q", tt.strategy, tt.item, rec.Reason, tt.want)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("%s didn't suggest %s", tt.strategy, tt.item)
+		}
+	}
+}
+
+func TestFormatDays(t *testing.T) {
+	tests := []struct {
+		days       float64
+		every, ago string
+	}{
+		{0, "day", "today"},
+		{0.4, "day", "today"},
+		{0.6, "day", "yesterday"},
+		{1, "day", "yesterday"},
+		{1.6, "2 days", "2 days ago"},
+		{6.8, "7 days", "7 days ago"},
+		{30, "30 days", "30 days ago"},
+	}
+	for _, tt := range tests {
+		if got := formatDays(tt.days); got != tt.every {
+			t.Errorf("formatDays(%v) = %q, want %q", tt.days, got, tt.every)
+		}
+		if got := formatDaysAgo(tt.days); got != tt.ago {
+			t.Errorf("formatDaysAgo(%v) = %q, want %q", tt.days, got, tt.ago)
+		}
+	}
+}
@@ -0,0 +1,463 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Scheduler settings
+const (
+	recurrenceCheckInterval = time.Minute
+	recurrenceBatchSize     = 50
+	recurrenceRetryDelay    = time.Hour // before a schedule that failed to fire is tried again
+	maxRecurrenceInterval   = 365
+)
+
+// ItemRecurrence is a schedule that brings an item back onto its list
+type ItemRecurrence struct {
+	ID          string     `json:"id"`
+	ListID      string     `json:"list_id"`
+	ItemID      *string    `json:"item_id"`
+	Name        string     `json:"name"`
+	Rule        string     `json:"rule"`
+	StartsAt    time.Time  `json:"starts_at"`
+	NextDueAt   time.Time  `json:"next_due_at"`
+	LastFiredAt *time.Time `json:"last_fired_at"`
+}
+
+// recurrenceRule is a small subset of iCalendar RRULE:
+// FREQ=DAILY|WEEKLY|MONTHLY, INTERVAL=n, BYDAY=MO,TU,... (weekly only), BYMONTHDAY=n (monthly only)
+// Occurrences fall on midnight UTC
+type recurrenceRule struct {
+	Freq       string
+	Interval   int
+	ByDay      []time.Weekday
+	ByMonthDay int
+}
+
+var weekdayCodes = map[string]time.Weekday{
+	"MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday, "TH": time.Thursday,
+	"FR": time.Friday, "SA": time.Saturday, "SU": time.Sunday,
+}
+
+// parseRecurrenceRule parses an RRULE-lite string such as "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH"
+func parseRecurrenceRule(s string) (recurrenceRule, error) {
+	rule := recurrenceRule{Interval: 1}
+	for _, part := range strings.Split(strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(s)), "RRULE:"), ";") {
+		if part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return rule, fmt.Errorf("invalid rule part %q", part)
+		}
+		switch key {
+		case "FREQ":
+			if value != "DAILY" && value != "WEEKLY" && value != "MONTHLY" {
+				return rule, fmt.Errorf("FREQ must be DAILY, WEEKLY or MONTHLY")
+			}
+			rule.Freq = value
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > maxRecurrenceInterval {
+				return rule, fmt.Errorf("INTERVAL must be between 1 and %d", maxRecurrenceInterval)
+			}
+			rule.Interval = n
+		case "BYDAY":
+			for _, code := range strings.Split(value, ",") {
+				day, ok := weekdayCodes[code]
+				if !ok {
+					return rule, fmt.Errorf("invalid weekday %q", code)
+				}
+				if !slices.Contains(rule.ByDay, day) {
+					rule.ByDay = append(rule.ByDay, day)
+				}
+			}
+		case "BYMONTHDAY":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > 31 {
+				return rule, fmt.Errorf("BYMONTHDAY must be between 1 and 31")
+			}
+			rule.ByMonthDay = n
+		default:
+			return rule, fmt.Errorf("unsupported rule part %s", key)
+		}
+	}
+
+	if rule.Freq == "" {
+		return rule, fmt.Errorf("FREQ is required")
+	}
+	if len(rule.ByDay) > 0 && rule.Freq != "WEEKLY" {
+		return rule, fmt.Errorf("BYDAY is only supported with FREQ=WEEKLY")
+	}
+	if rule.ByMonthDay > 0 && rule.Freq != "MONTHLY" {
+		return rule, fmt.Errorf("BYMONTHDAY is only supported with FREQ=MONTHLY")
+	}
+	return rule, nil
+}
+
+// String returns the canonical RRULE-lite form that is stored in the database
+func (rule recurrenceRule) String() string {
+	s := "FREQ=" + rule.Freq
+	if rule.Interval > 1 {
+		s += fmt.Sprintf(";INTERVAL=%d", rule.Interval)
+	}
+	if len(rule.ByDay) > 0 {
+		days := slices.Clone(rule.ByDay)
+		// Sort Monday-first
+		slices.SortFunc(days, func(a, b time.Weekday) int { return (int(a)+6)%7 - (int(b)+6)%7 })
+		codes := []string{}
+		for _, day := range days {
+			for code, d := range weekdayCodes {
+				if d == day {
+					codes = append(codes, code)
+				}
+			}
+		}
+		s += ";BYDAY=" + strings.Join(codes, ",")
+	}
+	if rule.ByMonthDay > 0 {
+		s += fmt.Sprintf(";BYMONTHDAY=%d", rule.ByMonthDay)
+	}
+	return s
+}
+
+// next returns the first occurrence strictly after `after`
+// `start` anchors the interval, e.g. every 2 weeks counted from the week of `start`
+func (rule recurrenceRule) next(start, after time.Time) time.Time {
+	startDay := truncateToDay(start)
+	day := truncateToDay(after).AddDate(0, 0, 1)
+	if day.Before(startDay) {
+		day = startDay
+	}
+
+	switch rule.Freq {
+	case "DAILY":
+		diff := daysBetween(startDay, day)
+		steps := (diff + rule.Interval - 1) / rule.Interval
+		return startDay.AddDate(0, 0, steps*rule.Interval)
+
+	case "WEEKLY":
+		byDay := rule.ByDay
+		if len(byDay) == 0 {
+			byDay = []time.Weekday{startDay.Weekday()}
+		}
+		startWeek := weekStart(startDay)
+		for i := 0; i < 7*(rule.Interval+1); i++ {
+			d := day.AddDate(0, 0, i)
+			week := daysBetween(startWeek, weekStart(d)) / 7
+			if week%rule.Interval == 0 && slices.Contains(byDay, d.Weekday()) {
+				return d
+			}
+		}
+
+	case "MONTHLY":
+		monthDay := rule.ByMonthDay
+		if monthDay == 0 {
+			monthDay = startDay.Day()
+		}
+		startMonth := startDay.Year()*12 + int(startDay.Month()) - 1
+		month := day.Year()*12 + int(day.Month()) - 1
+		for ; ; month++ {
+			if (month-startMonth)%rule.Interval != 0 {
+				continue
+			}
+			year, m := month/12, time.Month(month%12+1)
+			// Clamp e.g. the 31st to the last day of shorter months
+			lastDay := time.Date(year, m+1, 0, 0, 0, 0, 0, time.UTC).Day()
+			candidate := time.Date(year, m, min(monthDay, lastDay), 0, 0, 0, 0, time.UTC)
+			if !candidate.Before(day) {
+				return candidate
+			}
+		}
+	}
+
+	// Unreachable for valid rules - fall back to tomorrow
+	return day
+}
+
+// truncateToDay returns midnight UTC of the given time's day
+func truncateToDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// weekStart returns the Monday of the given day's week
+func weekStart(day time.Time) time.Time {
+	return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+}
+
+// daysBetween returns the number of whole days between two midnights
+func daysBetween(from, to time.Time) int {
+	return int(to.Sub(from).Hours()+12) / 24
+}
+
+// SetItemRecurrence handles PUT /api/lists/{listId}/items/{id}/recurrence - creates or replaces an item's schedule
+// Accepts {"rrule": "FREQ=WEEKLY;BYDAY=SA"}, {"interval_days": 14} or {"weekdays": ["MO", "TH"]}
+func SetItemRecurrence(w http.ResponseWriter, r *http.Request) {
+	listID := r.PathValue("listId")
+	id := r.PathValue("id")
+	if listID == "" || id == "" {
+		http.Error(w, "List ID and Item ID are required", http.StatusBadRequest)
+		return
+	}
+
+	var input struct {
+		RRule        string     `json:"rrule"`
+		IntervalDays int        `json:"interval_days"`
+		Weekdays     []string   `json:"weekdays"`
+		StartsAt     *time.Time `json:"starts_at"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	// The convenience forms are translated to RRULE-lite
+	ruleText := input.RRule
+	switch {
+	case ruleText != "":
+	case input.IntervalDays > 0:
+		ruleText = fmt.Sprintf("FREQ=DAILY;INTERVAL=%d", input.IntervalDays)
+	case len(input.Weekdays) > 0:
+		ruleText = "FREQ=WEEKLY;BYDAY=" + strings.Join(input.Weekdays, ",")
+	default:
+		http.Error(w, "One of rrule, interval_days or weekdays is required", http.StatusBadRequest)
+		return
+	}
+	rule, err := parseRecurrenceRule(ruleText)
+	if err != nil {
+		http.Error(w, "Invalid recurrence: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	startsAt := now
+	if input.StartsAt != nil {
+		startsAt = *input.StartsAt
+	}
+	// The first occurrence is the next one from now (or the start date, if that's in the future)
+	nextDue := rule.next(startsAt, now)
+	if startsAt.After(now) {
+		nextDue = rule.next(startsAt, startsAt.Add(-24*time.Hour))
+	}
+
+	var rec ItemRecurrence
+	err = DB.QueryRow(context.Background(),
+		`INSERT INTO item_recurrences (list_id, item_id, name, rule, starts_at, next_due_at)
+		 SELECT list_id, id, name, $3, $4, $5 FROM items
+		 WHERE id::text = $1 AND list_id = $2
+		 ON CONFLICT (item_id) DO UPDATE SET
+			rule = EXCLUDED.rule, starts_at = EXCLUDED.starts_at, next_due_at = EXCLUDED.next_due_at
+		 RETURNING id::text, list_id, item_id::text, name, rule, starts_at, next_due_at, last_fired_at`,
+		id, listID, rule.String(), startsAt, nextDue,
+	).Scan(&rec.ID, &rec.ListID, &rec.ItemID, &rec.Name, &rec.Rule, &rec.StartsAt, &rec.NextDueAt, &rec.LastFiredAt)
+	if err != nil {
+		http.Error(w, "Item not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rec)
+}
+
+// GetRecurrences handles GET /api/lists/{listId}/recurrences - returns all schedules of a list
+func GetRecurrences(w http.ResponseWriter, r *http.Request) {
+	listID := r.PathValue("listId")
+	if listID == "" {
+		http.Error(w, "List ID is required", http.StatusBadRequest)
+		return
+	}
+
+	rows, err := DB.Query(context.Background(),
+		`SELECT id::text, list_id, item_id::text, name, rule, starts_at, next_due_at, last_fired_at
+		 FROM item_recurrences WHERE list_id = $1
+		 ORDER BY next_due_at ASC`, listID)
+	if err != nil {
+		http.Error(w, "Failed to fetch recurrences", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	recs := []ItemRecurrence{}
+	for rows.Next() {
+		var rec ItemRecurrence
+		err := rows.Scan(&rec.ID, &rec.ListID, &rec.ItemID, &rec.Name, &rec.Rule,
+			&rec.StartsAt, &rec.NextDueAt, &rec.LastFiredAt)
+		if err != nil {
+			http.Error(w, "Failed to scan recurrence", http.StatusInternalServerError)
+			return
+		}
+		recs = append(recs, rec)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(recs)
+}
+
+// DeleteRecurrence handles DELETE /api/lists/{listId}/recurrences/{id} - stops a schedule (the item stays)
+func DeleteRecurrence(w http.ResponseWriter, r *http.Request) {
+	listID := r.PathValue("listId")
+	id := r.PathValue("id")
+	if listID == "" || id == "" {
+		http.Error(w, "List ID and Recurrence ID are required", http.StatusBadRequest)
+		return
+	}
+
+	result, err := DB.Exec(context.Background(),
+		"DELETE FROM item_recurrences WHERE id::text = $1 AND list_id = $2", id, listID)
+	if err != nil {
+		http.Error(w, "Failed to delete recurrence", http.StatusInternalServerError)
+		return
+	}
+	if result.RowsAffected() == 0 {
+		http.Error(w, "Recurrence not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// StartRecurrenceScheduler periodically re-adds or unchecks recurring items that are due
+// Safe to run on every replica: due rows are claimed with FOR UPDATE SKIP LOCKED,
+// so each occurrence is handled by exactly one server
+func StartRecurrenceScheduler() {
+	ticker := time.NewTicker(recurrenceCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			processed, err := processDueRecurrences(context.Background())
+			if err != nil {
+				log.Printf("Recurring items: %v", err)
+				break
+			}
+			if processed < recurrenceBatchSize {
+				break
+			}
+		}
+		<-ticker.C
+	}
+}
+
+// processDueRecurrences handles up to one batch of due schedules and returns how many were processed
+// Each schedule gets its own transaction, which locks at most one list, so one failing schedule
+// doesn't hold up the others and replicas can't deadlock on each other's list locks
+func processDueRecurrences(ctx context.Context) (int, error) {
+	for processed := 0; processed < recurrenceBatchSize; processed++ {
+		found, err := processNextRecurrence(ctx)
+		if err != nil || !found {
+			return processed, err
+		}
+	}
+	return recurrenceBatchSize, nil
+}
+
+// processNextRecurrence claims the oldest due schedule, fires it and schedules the next occurrence
+// Returns false if nothing is due. If firing fails, the schedule is retried after recurrenceRetryDelay
+func processNextRecurrence(ctx context.Context) (bool, error) {
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	var rec ItemRecurrence
+	err = tx.QueryRow(ctx,
+		`SELECT id::text, list_id, item_id::text, name, rule, starts_at, next_due_at, last_fired_at
+		 FROM item_recurrences
+		 WHERE next_due_at <= NOW()
+		 ORDER BY next_due_at ASC
+		 LIMIT 1
+		 FOR UPDATE SKIP LOCKED`).Scan(&rec.ID, &rec.ListID, &rec.ItemID, &rec.Name, &rec.Rule,
+		&rec.StartsAt, &rec.NextDueAt, &rec.LastFiredAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	// Fire inside a savepoint, so a failure can be undone while the schedule is still moved on
+	now := time.Now()
+	savepoint, err := tx.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	itemID, err := fireRecurrence(ctx, savepoint, rec)
+	if err == nil {
+		err = savepoint.Commit(ctx)
+	}
+	if err != nil {
+		savepoint.Rollback(ctx)
+		log.Printf("Recurring item %s: %v", rec.ID, err)
+		_, err := tx.Exec(ctx,
+			"UPDATE item_recurrences SET next_due_at = $2 WHERE id::text = $1",
+			rec.ID, now.Add(recurrenceRetryDelay))
+		if err != nil {
+			return false, err
+		}
+		return true, tx.Commit(ctx)
+	}
+
+	// Schedule the next occurrence (skipping any that were missed while the server was down)
+	var nextDue time.Time
+	if rule, err := parseRecurrenceRule(rec.Rule); err == nil {
+		nextDue = rule.next(rec.StartsAt, now)
+	} else {
+		// A rule that no longer parses would fire every minute - push it out a day instead
+		nextDue = now.Add(24 * time.Hour)
+	}
+	_, err = tx.Exec(ctx,
+		`UPDATE item_recurrences SET item_id = NULLIF($2, '')::uuid, next_due_at = $3, last_fired_at = NOW()
+		 WHERE id::text = $1`,
+		rec.ID, itemID, nextDue)
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit(ctx)
+}
+
+// fireRecurrence unchecks the item, or re-adds it at the end of the list if it was deleted
+// Returns the (possibly new) item ID
+func fireRecurrence(ctx context.Context, tx pgx.Tx, rec ItemRecurrence) (string, error) {
+	if rec.ItemID != nil {
+		item := Item{ID: *rec.ItemID}
+		err := tx.QueryRow(ctx,
+			"UPDATE items SET checked = false WHERE id::text = $1 AND list_id = $2 RETURNING parent_id::text",
+			item.ID, rec.ListID).Scan(&item.ParentID)
+		if err == nil {
+			// Its sub-items are due again too, and its parent isn't done any more
+			if err := checkItem(ctx, tx, item); err != nil {
+				return "", err
+			}
+			return item.ID, nil
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return "", err
+		}
+	}
+
+	maxOrder, err := lockListForAppend(ctx, tx, rec.ListID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	item, err := insertItem(ctx, tx, rec.ListID, itemSeed{Name: rec.Name}, maxOrder+rankStep)
+	if err != nil {
+		return "", err
+	}
+	return item.ID, nil
+}
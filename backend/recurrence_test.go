@@ -0,0 +1,91 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRecurrenceRule(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string // canonical form, empty if the rule is invalid
+		wantErr bool
+	}{
+		{in: "FREQ=DAILY", want: "FREQ=DAILY"},
+		{in: "rrule:freq=weekly;interval=2;byday=th,mo", want: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH"},
+		{in: "FREQ=WEEKLY;BYDAY=SU,MO,SU", want: "FREQ=WEEKLY;BYDAY=MO,SU"},
+		{in: "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=31", want: "FREQ=MONTHLY;BYMONTHDAY=31"},
+		{in: " FREQ=DAILY; ", want: "FREQ=DAILY"},
+		{in: "", wantErr: true},
+		{in: "INTERVAL=2", wantErr: true},
+		{in: "FREQ=YEARLY", wantErr: true},
+		{in: "FREQ=DAILY;INTERVAL=0", wantErr: true},
+		{in: "FREQ=DAILY;INTERVAL=366", wantErr: true},
+		{in: "FREQ=WEEKLY;BYDAY=XX", wantErr: true},
+		{in: "FREQ=DAILY;BYDAY=MO", wantErr: true},
+		{in: "FREQ=WEEKLY;BYMONTHDAY=1", wantErr: true},
+		{in: "FREQ=MONTHLY;BYMONTHDAY=32", wantErr: true},
+		{in: "FREQ=DAILY;COUNT=3", wantErr: true},
+		{in: "FREQ", wantErr: true},
+	}
+	for _, tt := range tests {
+		rule, err := parseRecurrenceRule(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseRecurrenceRule(%q) = %q, want an error", tt.in, rule)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseRecurrenceRule(%q): %v", tt.in, err)
+			continue
+		}
+		if got := rule.String(); got != tt.want {
+			t.Errorf("parseRecurrenceRule(%q).String() = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRecurrenceRuleNext(t *testing.T) {
+	day := func(s string) time.Time {
+		t.Helper()
+		d, err := time.Parse(time.DateOnly, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	tests := []struct {
+		name  string
+		rule  string
+		start string
+		after time.Time
+		want  string
+	}{
+		{"daily", "FREQ=DAILY", "2026-01-05", day("2026-01-10").Add(15 * time.Hour), "2026-01-11"},
+		{"every third day", "FREQ=DAILY;INTERVAL=3", "2026-01-05", day("2026-01-06"), "2026-01-08"},
+		{"not before the start", "FREQ=DAILY", "2026-03-01", day("2026-01-01"), "2026-03-01"},
+		{"weekly on the start's weekday", "FREQ=WEEKLY", "2026-01-05", day("2026-01-05"), "2026-01-12"},
+		{"weekly on given days", "FREQ=WEEKLY;BYDAY=MO,TH", "2026-01-05", day("2026-01-05"), "2026-01-08"},
+		{"every other week skips odd weeks", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH", "2026-01-05", day("2026-01-09"), "2026-01-19"},
+		{"monthly on the start's day", "FREQ=MONTHLY", "2026-01-15", day("2026-01-15"), "2026-02-15"},
+		{"31st clamps to short months", "FREQ=MONTHLY;BYMONTHDAY=31", "2026-01-31", day("2026-01-31"), "2026-02-28"},
+		{"every third month", "FREQ=MONTHLY;INTERVAL=3", "2026-01-15", day("2026-01-20"), "2026-04-15"},
+		{"across a year", "FREQ=MONTHLY", "2025-12-10", day("2025-12-10"), "2026-01-10"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := parseRecurrenceRule(tt.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := rule.next(day(tt.start), tt.after)
+			if want := day(tt.want); !got.Equal(want) {
+				t.Errorf("next(%s, %s) = %s, want %s", tt.start, tt.after, got.Format(time.DateOnly), tt.want)
+			}
+			if !got.After(tt.after) {
+				t.Errorf("next(%s, %s) = %s is not after %s", tt.start, tt.after, got, tt.after)
+			}
+		})
+	}
+}
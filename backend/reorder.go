@@ -0,0 +1,291 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Items are ordered by sort_order among their siblings (same section and parent), a fractional rank: moving an item gives it the midpoint
+// of its new neighbours, so only that one row changes. Every bisection halves the gap,
+// so once ranks get too close the list is renumbered 1, 2, 3, ... (rebalanced)
+const (
+	rankStep = 1.0 // gap between neighbours after a rebalance, and when moving to either end
+	// Gaps below this are rebalanced in the background, long before float64 runs out of precision
+	rankDenseGap = 1e-6
+)
+
+var (
+	errNeighbourNotFound = errors.New("neighbour not found")
+	errNotAdjacent       = errors.New("after_id and before_id are not adjacent")
+)
+
+// rankedItem is an item's place in the list order
+type rankedItem struct {
+	ID   string
+	Rank float64
+}
+
+// itemPlace is the group of siblings an item is ranked among: its section and parent (nil for none)
+type itemPlace struct {
+	SectionID *string
+	ParentID  *string
+}
+
+// loadRanks returns the items of a group of siblings in display order, without excludeID (the item being moved)
+func loadRanks(ctx context.Context, q querier, listID string, place itemPlace, excludeID string) ([]rankedItem, error) {
+	rows, err := q.Query(ctx,
+		`SELECT id::text, COALESCE(sort_order, 0) FROM items
+		 WHERE list_id = $1 AND id::text <> $2
+			AND section_id::text IS NOT DISTINCT FROM $3 AND parent_id::text IS NOT DISTINCT FROM $4
+		 ORDER BY sort_order ASC, created_at DESC`, listID, excludeID, place.SectionID, place.ParentID)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToStructByPos[rankedItem])
+}
+
+// insertPosition finds where an item goes: directly after afterID and/or directly before beforeID
+// With neither, the item goes to the end
+func insertPosition(order []rankedItem, afterID, beforeID string) (int, error) {
+	index := func(id string) int {
+		for i, item := range order {
+			if item.ID == id {
+				return i
+			}
+		}
+		return -1
+	}
+
+	pos := len(order)
+	if afterID != "" {
+		i := index(afterID)
+		if i < 0 {
+			return 0, errNeighbourNotFound
+		}
+		pos = i + 1
+	}
+	if beforeID != "" {
+		i := index(beforeID)
+		if i < 0 {
+			return 0, errNeighbourNotFound
+		}
+		// Both given: the client's view of the list must still match
+		if afterID != "" && i != pos {
+			return 0, errNotAdjacent
+		}
+		pos = i
+	}
+	return pos, nil
+}
+
+// rankAt returns a rank between the neighbours of position pos, and the smaller of the two gaps it leaves
+// ok is false if the neighbours are too close (or equal) to fit a rank between them
+func rankAt(order []rankedItem, pos int) (rank, gap float64, ok bool) {
+	switch {
+	case len(order) == 0:
+		return rankStep, rankStep, true
+	case pos == 0:
+		return order[0].Rank - rankStep, rankStep, true
+	case pos == len(order):
+		return order[pos-1].Rank + rankStep, rankStep, true
+	}
+	lo, hi := order[pos-1].Rank, order[pos].Rank
+	rank = lo + (hi-lo)/2
+	gap = math.Min(rank-lo, hi-rank)
+	return rank, gap, lo < rank && rank < hi
+}
+
+// rankFor returns the sort_order and place for an item put after afterID and/or before beforeID,
+// among that neighbour's siblings. Without neighbours, the item goes to the end of place.
+// Has to run inside a transaction that locked the list (lockListForAppend). If the neighbours' ranks
+// are exhausted, the list is rebalanced right away; if they're merely dense, dense is true
+// and the caller should schedule rebalanceInBackground after committing
+func rankFor(ctx context.Context, tx pgx.Tx, listID, itemID string, place itemPlace, afterID, beforeID string) (rank float64, _ itemPlace, dense bool, err error) {
+	if neighbour := cmp.Or(afterID, beforeID); neighbour != "" {
+		err := tx.QueryRow(ctx,
+			"SELECT section_id::text, parent_id::text FROM items WHERE list_id = $1 AND id::text = $2",
+			listID, neighbour).Scan(&place.SectionID, &place.ParentID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, place, false, errNeighbourNotFound
+		}
+		if err != nil {
+			return 0, place, false, err
+		}
+	}
+
+	order, err := loadRanks(ctx, tx, listID, place, itemID)
+	if err != nil {
+		return 0, place, false, err
+	}
+	// A before_id among other siblings than after_id isn't in this order
+	pos, err := insertPosition(order, afterID, beforeID)
+	if errors.Is(err, errNeighbourNotFound) && afterID != "" && beforeID != "" {
+		err = errNotAdjacent
+	}
+	if err != nil {
+		return 0, place, false, err
+	}
+
+	rank, gap, ok := rankAt(order, pos)
+	if !ok {
+		if err := rebalanceRanks(ctx, tx, listID); err != nil {
+			return 0, place, false, err
+		}
+		if order, err = loadRanks(ctx, tx, listID, place, itemID); err != nil {
+			return 0, place, false, err
+		}
+		rank, gap, _ = rankAt(order, pos)
+	}
+	return rank, place, gap < rankDenseGap, nil
+}
+
+// rebalanceRanks renumbers a list's items 1, 2, 3, ... in their current (tree) order
+func rebalanceRanks(ctx context.Context, q querier, listID string) error {
+	_, err := q.Exec(ctx,
+		`UPDATE items SET sort_order = o.rn * $2
+		 FROM (
+			SELECT i.id, row_number() OVER (ORDER BY `+itemTreeOrder+`) AS rn
+			FROM items i `+itemTreeJoins+`
+			WHERE i.list_id = $1
+		 ) o
+		 WHERE items.id = o.id AND items.sort_order IS DISTINCT FROM o.rn * $2`,
+		listID, rankStep)
+	return err
+}
+
+// Lists with a rebalance queued or running
+var rebalancing sync.Map
+
+// rebalanceInBackground renumbers a list's ranks without holding up the request that made them dense
+func rebalanceInBackground(listID string) {
+	if _, running := rebalancing.LoadOrStore(listID, true); running {
+		return
+	}
+	go func() {
+		defer rebalancing.Delete(listID)
+
+		ctx := context.Background()
+		tx, err := DB.Begin(ctx)
+		if err != nil {
+			log.Printf("Rebalance %s: %v", listID, err)
+			return
+		}
+		defer tx.Rollback(ctx)
+
+		if _, err := lockListForAppend(ctx, tx, listID); err != nil {
+			return // list was deleted
+		}
+		if err := rebalanceRanks(ctx, tx, listID); err != nil {
+			log.Printf("Rebalance %s: %v", listID, err)
+			return
+		}
+		if err := tx.Commit(ctx); err != nil {
+			log.Printf("Rebalance %s: %v", listID, err)
+		}
+	}()
+}
+
+// MoveItemPosition handles PUT /api/lists/{listId}/items/{id}/position - moves one item
+// Body: {"after_id": "...", "before_id": "..."}, either may be left out (top or bottom of the section).
+// The item joins its neighbours' section and parent, so dropping it among sub-items nests it;
+// with neither, it goes to the end of "section_id" (none if empty) as a top-level item.
+// Only the moved item's sort_order, section and parent change (and its sub-items follow it)
+func MoveItemPosition(w http.ResponseWriter, r *http.Request) {
+	listID := r.PathValue("listId")
+	id := r.PathValue("id")
+
+	var input struct {
+		AfterID   string `json:"after_id"`
+		BeforeID  string `json:"before_id"`
+		SectionID string `json:"section_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if input.AfterID == id || input.BeforeID == id {
+		http.Error(w, "An item can't be placed next to itself", http.StatusBadRequest)
+		return
+	}
+
+	ctx := context.Background()
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		http.Error(w, "Failed to move item", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := lockListForAppend(ctx, tx, listID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, "List not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to move item", http.StatusInternalServerError)
+		return
+	}
+
+	section, err := resolveSection(ctx, tx, listID, input.SectionID, "")
+	if errors.Is(err, errSectionNotFound) {
+		http.Error(w, "Section not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to move item", http.StatusInternalServerError)
+		return
+	}
+
+	rank, place, dense, err := rankFor(ctx, tx, listID, id, itemPlace{SectionID: section}, input.AfterID, input.BeforeID)
+	if errors.Is(err, errNeighbourNotFound) {
+		http.Error(w, "Neighbour item not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, errNotAdjacent) {
+		http.Error(w, "List has changed, reload it", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to move item", http.StatusInternalServerError)
+		return
+	}
+
+	// updateItem takes an empty section_id or parent_id as "none"
+	sectionID, parentID := "", ""
+	if place.SectionID != nil {
+		sectionID = *place.SectionID
+	}
+	if place.ParentID != nil {
+		parentID = *place.ParentID
+	}
+	item, err := updateItem(ctx, tx, listID, id, itemUpdate{SortOrder: &rank, SectionID: &sectionID, ParentID: &parentID})
+	if errors.Is(err, errNestingTooDeep) {
+		http.Error(w, "Sub-items can't have sub-items of their own", http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "Item not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to move item", http.StatusInternalServerError)
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		http.Error(w, "Failed to move item", http.StatusInternalServerError)
+		return
+	}
+	if dense {
+		rebalanceInBackground(listID)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item)
+}
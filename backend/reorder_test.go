@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestInsertPosition(t *testing.T) {
+	order := []rankedItem{{"a", 1}, {"b", 2}, {"c", 3}}
+	tests := []struct {
+		name            string
+		afterID, before string
+		want            int
+		wantErr         error
+	}{
+		{"no neighbours goes to the end", "", "", 3, nil},
+		{"after the first", "a", "", 1, nil},
+		{"after the last", "c", "", 3, nil},
+		{"before the first", "", "a", 0, nil},
+		{"before the last", "", "c", 2, nil},
+		{"between adjacent items", "a", "b", 1, nil},
+		{"between items that aren't adjacent", "a", "c", 0, errNotAdjacent},
+		{"in the wrong order", "b", "a", 0, errNotAdjacent},
+		{"unknown after_id", "x", "", 0, errNeighbourNotFound},
+		{"unknown before_id", "", "x", 0, errNeighbourNotFound},
+		{"unknown before_id with after_id", "a", "x", 0, errNeighbourNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := insertPosition(order, tt.afterID, tt.before)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("position = %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	if got, err := insertPosition(nil, "", ""); err != nil || got != 0 {
+		t.Errorf("empty list: position %d, error %v, want 0", got, err)
+	}
+}
+
+func TestRankAt(t *testing.T) {
+	order := []rankedItem{{"a", 1}, {"b", 2}, {"c", 4}}
+	tests := []struct {
+		name      string
+		order     []rankedItem
+		pos       int
+		rank, gap float64
+		ok        bool
+	}{
+		{"empty list", nil, 0, rankStep, rankStep, true},
+		{"before the first", order, 0, 1 - rankStep, rankStep, true},
+		{"after the last", order, 3, 4 + rankStep, rankStep, true},
+		{"midpoint", order, 1, 1.5, 0.5, true},
+		{"wider gap", order, 2, 3, 1, true},
+		{"equal neighbours", []rankedItem{{"a", 2}, {"b", 2}}, 1, 2, 0, false},
+		{"no float between neighbours", []rankedItem{{"a", 1}, {"b", 1 + 2.220446049250313e-16}}, 1, 1, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rank, gap, ok := rankAt(tt.order, tt.pos)
+			if ok != tt.ok {
+				t.Fatalf("ok = %t, want %t", ok, tt.ok)
+			}
+			if ok && (rank != tt.rank || gap != tt.gap) {
+				t.Errorf("rank %v with gap %v, want %v with gap %v", rank, gap, tt.rank, tt.gap)
+			}
+		})
+	}
+}
+
+func TestRankAtDenseBeforeExhausted(t *testing.T) {
+	// Moving items between the same two neighbours over and over halves the gap each time;
+	// the list has to be reported dense well before no rank fits anymore
+	order := []rankedItem{{"a", 1}, {"b", 2}}
+	dense := false
+	for i := 0; ; i++ {
+		rank, gap, ok := rankAt(order, 1)
+		if !ok {
+			if !dense {
+				t.Fatalf("ranks ran out after %d moves without being reported dense", i)
+			}
+			return
+		}
+		dense = dense || gap < rankDenseGap
+		order[1].Rank = rank
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+	_ "time/tzdata" // ?tz= must work on hosts without a zoneinfo database
+)
+
+// Seasonality settings
+const (
+	minSeasonalAdds   = 4   // items added fewer times have no reliable pattern
+	seasonalSmoothing = 2.0 // pseudo-count per weekday/month, pulls sparse data towards "no pattern"
+	minSeasonalFactor = 0.25
+	maxSeasonalFactor = 3.0
+	notableFactor     = 1.5 // boosts above this are mentioned in the reason
+)
+
+// seasonality counts an item's additions per weekday and month
+// since is when the list's addition timeline starts; months are only compared over the time observed
+type seasonality struct {
+	weekdays [7]int
+	months   [12]int
+	total    int
+	since    time.Time
+}
+
+// add records one addition (t must already be in the list's time zone)
+func (s *seasonality) add(t time.Time) {
+	s.weekdays[t.Weekday()]++
+	s.months[t.Month()-1]++
+	s.total++
+}
+
+// weekdayFactor is how much more likely an addition on this weekday is than on an average day
+func (s *seasonality) weekdayFactor(day time.Weekday) float64 {
+	return smoothedRatio(s.weekdays[day], s.total, 7)
+}
+
+// monthFactor is how much more likely an addition in now's month is than in an average month
+// Each month is expected to get additions in proportion to how many of its days the timeline covers,
+// so a list with two months of history isn't credited with a pattern for the ten months it hasn't seen
+func (s *seasonality) monthFactor(now time.Time) float64 {
+	exposure := monthExposure(s.since, now)
+	observed := 0.0
+	for _, days := range exposure {
+		observed += days
+	}
+	if observed <= 0 {
+		return 1
+	}
+	expected := float64(s.total) * exposure[now.Month()-1] / observed
+	return (float64(s.months[now.Month()-1]) + seasonalSmoothing) / (expected + seasonalSmoothing)
+}
+
+// monthExposure returns how many days of each calendar month lie between since and until
+func monthExposure(since, until time.Time) [12]float64 {
+	var days [12]float64
+	start := since.In(until.Location())
+	for start.Before(until) {
+		next := time.Date(start.Year(), start.Month()+1, 1, 0, 0, 0, 0, start.Location())
+		end := next
+		if until.Before(end) {
+			end = until
+		}
+		days[start.Month()-1] += end.Sub(start).Hours() / 24
+		start = next
+	}
+	return days
+}
+
+// smoothedRatio compares one bucket's share with a uniform share, with additive smoothing
+func smoothedRatio(count, total, buckets int) float64 {
+	n := float64(buckets)
+	return (float64(count) + seasonalSmoothing) / (float64(total) + n*seasonalSmoothing) * n
+}
+
+// seasonalRecommender scales another strategy's urgencies by the item's weekday and month pattern,
+// e.g. barbecue items on Saturdays or baking supplies in December
+// The wrapped strategy should use a lower threshold so boosted items can still make it past minUrgency
+type seasonalRecommender struct {
+	Recommender
+	minUrgency float64
+}
+
+func (seasonalRecommender) Name() string { return "seasonal" }
+
+func (s seasonalRecommender) Recommend(in RecommendationInput) []Recommendation {
+	recs := []Recommendation{}
+	for _, rec := range s.Recommender.Recommend(in) {
+		season := in.Seasonality[rec.Name]
+		if season != nil && season.total >= minSeasonalAdds {
+			weekday := season.weekdayFactor(in.Now.Weekday())
+			month := season.monthFactor(in.Now)
+			rec.Urgency *= min(max(weekday*month, minSeasonalFactor), maxSeasonalFactor)
+
+			switch {
+			case month >= notableFactor && month >= weekday:
+				rec.Reason += fmt.Sprintf("; often bought in %s", in.Now.Month())
+			case weekday >= notableFactor:
+				rec.Reason += fmt.Sprintf("; often bought on %ss", in.Now.Weekday())
+			}
+		}
+		if rec.Urgency >= s.minUrgency {
+			recs = append(recs, rec)
+		}
+	}
+	return recs
+}
+
+// loadSeasonality counts each item's additions per weekday and month in the given time zone
+func loadSeasonality(ctx context.Context, listID string, loc *time.Location) (map[string]*seasonality, error) {
+	rows, err := DB.Query(ctx,
+		`SELECT item_name,
+			EXTRACT(DOW FROM added_at AT TIME ZONE $2)::int,
+			EXTRACT(MONTH FROM added_at AT TIME ZONE $2)::int,
+			COUNT(*)
+		FROM item_additions
+		WHERE list_id = $1
+		GROUP BY 1, 2, 3`,
+		listID, loc.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var since *time.Time
+	err = DB.QueryRow(ctx, "SELECT MIN(added_at) FROM item_additions WHERE list_id = $1", listID).Scan(&since)
+	if err != nil {
+		return nil, err
+	}
+
+	seasons := map[string]*seasonality{}
+	for rows.Next() {
+		var name string
+		var weekday, month, count int
+		if err := rows.Scan(&name, &weekday, &month, &count); err != nil {
+			return nil, err
+		}
+		if seasons[name] == nil {
+			seasons[name] = &seasonality{since: *since}
+		}
+		seasons[name].weekdays[weekday] += count
+		seasons[name].months[month-1] += count
+		seasons[name].total += count
+	}
+	return seasons, rows.Err()
+}
+
+// requestLocation returns the time zone from ?tz= (IANA name), or UTC
+func requestLocation(tz string) (*time.Location, error) {
+	if tz == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(tz)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestMonthExposure(t *testing.T) {
+	date := func(y int, m time.Month, d, h int) time.Time { return time.Date(y, m, d, h, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		name         string
+		since, until time.Time
+		want         map[time.Month]float64
+	}{
+		{"empty span", date(2026, 3, 1, 0), date(2026, 3, 1, 0), nil},
+		{"whole months", date(2026, 1, 1, 0), date(2026, 3, 1, 0), map[time.Month]float64{1: 31, 2: 28}},
+		{"partial months", date(2026, 1, 15, 12), date(2026, 2, 10, 0), map[time.Month]float64{1: 16.5, 2: 9}},
+		{"across a year", date(2025, 12, 20, 0), date(2026, 1, 5, 0), map[time.Month]float64{12: 12, 1: 4}},
+		{"same month twice", date(2025, 10, 31, 0), date(2026, 10, 31, 0), map[time.Month]float64{
+			1: 31, 2: 28, 3: 31, 4: 30, 5: 31, 6: 30, 7: 31, 8: 31, 9: 30, 10: 31, 11: 30, 12: 31}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := monthExposure(tt.since, tt.until)
+			for m := time.January; m <= time.December; m++ {
+				if math.Abs(got[m-1]-tt.want[m]) > 1e-9 {
+					t.Errorf("%s: %v days, want %v", m, got[m-1], tt.want[m])
+				}
+			}
+		})
+	}
+}
+
+// monthlyAdditions builds a seasonality from additions per month, all on the 10th at noon
+func monthlyAdditions(since time.Time, perMonth map[time.Month]int) *seasonality {
+	s := &seasonality{since: since}
+	for m, n := range perMonth {
+		for range n {
+			s.add(time.Date(2026, m, 10, 12, 0, 0, 0, time.UTC))
+		}
+	}
+	return s
+}
+
+func TestMonthFactor(t *testing.T) {
+	oct31 := time.Date(2026, 10, 31, 0, 0, 0, 0, time.UTC)
+	everyMonth := map[time.Month]int{}
+	for m := time.January; m <= time.December; m++ {
+		everyMonth[m] = 12
+	}
+	december := map[time.Month]int{12: 20}
+	for m := time.January; m < time.December; m++ {
+		december[m] = 1
+	}
+
+	tests := []struct {
+		name     string
+		s        *seasonality
+		now      time.Time
+		min, max float64
+	}{
+		{"nothing observed", monthlyAdditions(oct31, map[time.Month]int{10: 5}), oct31, 1, 1},
+		{"two months of history aren't a pattern",
+			monthlyAdditions(time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC), map[time.Month]int{9: 5, 10: 5}),
+			oct31, 0.99, 1.01},
+		{"a full year of regular additions", monthlyAdditions(oct31.AddDate(-1, 0, 0), everyMonth), oct31, 0.95, 1.05},
+		{"a December item in December", monthlyAdditions(oct31.AddDate(-1, 0, 0), december),
+			time.Date(2026, 12, 15, 0, 0, 0, 0, time.UTC), notableFactor, math.Inf(1)},
+		{"a December item in October", monthlyAdditions(oct31.AddDate(-1, 0, 0), december), oct31, 0, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.s.monthFactor(tt.now); got < tt.min || got > tt.max {
+				t.Errorf("monthFactor = %v, want between %v and %v", got, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestWeekdayFactor(t *testing.T) {
+	monday := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	weekly := &seasonality{}
+	for d := range 14 {
+		weekly.add(monday.AddDate(0, 0, d))
+	}
+	saturdays := &seasonality{}
+	for w := range 7 {
+		saturdays.add(monday.AddDate(0, 0, 5+7*w))
+	}
+
+	tests := []struct {
+		name string
+		s    *seasonality
+		day  time.Weekday
+		want float64
+	}{
+		{"every day", weekly, time.Wednesday, 1},
+		{"Saturday item on a Saturday", saturdays, time.Saturday, 3},
+		{"Saturday item on a Sunday", saturdays, time.Sunday, 2.0 / 21 * 7},
+		{"no additions", &seasonality{}, time.Monday, 1},
+	}
+	for _, tt := range tests {
+		if got := tt.s.weekdayFactor(tt.day); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: weekdayFactor = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDefaultRecommenderUsesSeasonality(t *testing.T) {
+	t.Setenv("RECOMMENDER", "")
+	saturday := time.Date(2026, 3, 7, 10, 0, 0, 0, time.UTC)
+	interval := 7.0
+	history := []ItemHistory{{ItemName: "Charcoal", AddedCount: 8, LastAddedAt: saturday.AddDate(0, 0, -6),
+		AvgDaysBetween: &interval, IntervalEWMA: &interval}}
+	season := &seasonality{since: saturday.AddDate(0, -2, 0)}
+	for w := 1; w <= 8; w++ {
+		season.add(saturday.AddDate(0, 0, -7*w))
+	}
+
+	urgency := func(now time.Time) float64 {
+		in := RecommendationInput{Now: now, History: history, Seasonality: map[string]*seasonality{"Charcoal": season}}
+		recs := defaultRecommender().Recommend(in)
+		if len(recs) != 1 {
+			t.Fatalf("got %d recommendations on %s, want 1", len(recs), now.Weekday())
+		}
+		return recs[0].Urgency
+	}
+	plain := 6.0 / 7
+	if got := urgency(saturday); got <= plain {
+		t.Errorf("urgency on a Saturday = %v, want more than the plain %v", got, plain)
+	}
+}
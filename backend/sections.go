@@ -0,0 +1,281 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Section limits
+const (
+	maxSectionNameLength = 100
+	maxCategoryLength    = 50
+)
+
+var errSectionNotFound = errors.New("section not found")
+
+// Section is a named group of items on a list (e.g. "Dairy"), shown as a collapsible heading
+// Items without a section come first, then the sections in sort_order
+type Section struct {
+	ID        string    `json:"id"`
+	ListID    string    `json:"list_id"`
+	Name      string    `json:"name"`
+	HexColor  *string   `json:"hex_color"`
+	Category  *string   `json:"category"` // items added with this category go into the section
+	Collapsed bool      `json:"collapsed"`
+	SortOrder float64   `json:"sort_order"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// GetSections handles GET /api/lists/{listId}/sections - returns a list's sections in order
+func GetSections(w http.ResponseWriter, r *http.Request) {
+	rows, err := DB.Query(context.Background(),
+		`SELECT id::text, list_id, name, hex_color, category, collapsed, sort_order, created_at
+		 FROM list_sections WHERE list_id = $1
+		 ORDER BY sort_order ASC, created_at ASC`, r.PathValue("listId"))
+	if err != nil {
+		http.Error(w, "Failed to fetch sections", http.StatusInternalServerError)
+		return
+	}
+	sections, err := pgx.CollectRows(rows, pgx.RowToStructByPos[Section])
+	if err != nil {
+		http.Error(w, "Failed to fetch sections", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sections)
+}
+
+// sectionInput holds the fields of a section to create or change; nil fields are left as they are
+// An empty hex_color or category removes it
+type sectionInput struct {
+	Name      *string  `json:"name"`
+	HexColor  *string  `json:"hex_color"`
+	Category  *string  `json:"category"`
+	Collapsed *bool    `json:"collapsed"`
+	SortOrder *float64 `json:"sort_order"`
+}
+
+func (s sectionInput) validate() error {
+	if s.Name != nil && len(*s.Name) > maxSectionNameLength {
+		return fmt.Errorf("Section name must be %d characters or less", maxSectionNameLength)
+	}
+	if s.HexColor != nil && *s.HexColor != "" && !validHexColor(*s.HexColor) {
+		return errors.New("Invalid hex color")
+	}
+	if s.Category != nil && len(*s.Category) > maxCategoryLength {
+		return fmt.Errorf("Category must be %d characters or less", maxCategoryLength)
+	}
+	return nil
+}
+
+// CreateSection handles POST /api/lists/{listId}/sections - adds a section below the others
+func CreateSection(w http.ResponseWriter, r *http.Request) {
+	listID := r.PathValue("listId")
+
+	var input sectionInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if err := input.validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := context.Background()
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		http.Error(w, "Failed to create section", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := lockListForAppend(ctx, tx, listID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, "List not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to create section", http.StatusInternalServerError)
+		return
+	}
+
+	section, err := insertSection(ctx, tx, listID, input)
+	if err != nil {
+		http.Error(w, "Failed to create section", http.StatusInternalServerError)
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		http.Error(w, "Failed to create section", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(section)
+}
+
+// insertSection adds a section after a list's last one, unless input has a sort_order
+// Has to run inside a transaction that locked the list (lockListForAppend)
+func insertSection(ctx context.Context, q querier, listID string, input sectionInput) (Section, error) {
+	name := ""
+	if input.Name != nil {
+		name = *input.Name
+	}
+	collapsed := input.Collapsed != nil && *input.Collapsed
+
+	var s Section
+	err := q.QueryRow(ctx,
+		`INSERT INTO list_sections (list_id, name, hex_color, category, collapsed, sort_order)
+		 VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), $5,
+			COALESCE($6, COALESCE((SELECT MAX(sort_order) FROM list_sections WHERE list_id = $1), 0) + $7))
+		 RETURNING id::text, list_id, name, hex_color, category, collapsed, sort_order, created_at`,
+		listID, name, input.HexColor, input.Category, collapsed, input.SortOrder, rankStep,
+	).Scan(&s.ID, &s.ListID, &s.Name, &s.HexColor, &s.Category, &s.Collapsed, &s.SortOrder, &s.CreatedAt)
+	return s, err
+}
+
+// UpdateSection handles PATCH /api/lists/{listId}/sections/{id} - renames, recolours, collapses or moves a section
+func UpdateSection(w http.ResponseWriter, r *http.Request) {
+	listID := r.PathValue("listId")
+	id := r.PathValue("id")
+
+	var input sectionInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if err := input.validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if input.Name == nil && input.HexColor == nil && input.Category == nil &&
+		input.Collapsed == nil && input.SortOrder == nil {
+		http.Error(w, "No fields to update", http.StatusBadRequest)
+		return
+	}
+
+	// Fields that aren't given keep their value
+	var s Section
+	err := DB.QueryRow(context.Background(),
+		`UPDATE list_sections SET
+			name = COALESCE($3, name),
+			hex_color = CASE WHEN $4::text IS NULL THEN hex_color ELSE NULLIF($4, '') END,
+			category = CASE WHEN $5::text IS NULL THEN category ELSE NULLIF($5, '') END,
+			collapsed = COALESCE($6, collapsed),
+			sort_order = COALESCE($7, sort_order)
+		 WHERE id::text = $1 AND list_id = $2
+		 RETURNING id::text, list_id, name, hex_color, category, collapsed, sort_order, created_at`,
+		id, listID, input.Name, input.HexColor, input.Category, input.Collapsed, input.SortOrder,
+	).Scan(&s.ID, &s.ListID, &s.Name, &s.HexColor, &s.Category, &s.Collapsed, &s.SortOrder, &s.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "Section not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to update section", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s)
+}
+
+// DeleteSection handles DELETE /api/lists/{listId}/sections/{id} - removes a section
+// Its items stay on the list without a section
+func DeleteSection(w http.ResponseWriter, r *http.Request) {
+	result, err := DB.Exec(context.Background(),
+		"DELETE FROM list_sections WHERE id::text = $1 AND list_id = $2",
+		r.PathValue("id"), r.PathValue("listId"))
+	if err != nil {
+		http.Error(w, "Failed to delete section", http.StatusInternalServerError)
+		return
+	}
+	if result.RowsAffected() == 0 {
+		http.Error(w, "Section not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// resolveSection returns the section a new or changed item goes into: the given section of the list,
+// or else the first section bound to the category. nil (no section) if neither is given or no section
+// has the category. Returns errSectionNotFound if sectionID isn't a section of the list
+func resolveSection(ctx context.Context, q querier, listID, sectionID, category string) (*string, error) {
+	var id string
+	var err error
+	switch {
+	case sectionID != "":
+		err = q.QueryRow(ctx,
+			"SELECT id::text FROM list_sections WHERE id::text = $1 AND list_id = $2",
+			sectionID, listID).Scan(&id)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errSectionNotFound
+		}
+	case category != "":
+		err = q.QueryRow(ctx,
+			`SELECT id::text FROM list_sections WHERE list_id = $1 AND LOWER(category) = LOWER($2)
+			 ORDER BY sort_order ASC LIMIT 1`,
+			listID, category).Scan(&id)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+// listSeeds returns a list's items in display order for copying into a new list or a template
+// Each section becomes a separator seed with its name, placed before its items, and sub-items
+// point to their parent's seed
+func listSeeds(ctx context.Context, q querier, listID string, excludeChecked bool) ([]itemSeed, error) {
+	rows, err := q.Query(ctx,
+		`SELECT id, parent_id, name, checked, is_separator FROM (
+			SELECT i.id::text, i.parent_id::text, i.name, i.checked, false AS is_separator,
+				s.sort_order AS section_order, s.created_at AS section_created,
+				COALESCE(p.sort_order, i.sort_order) AS top_order, COALESCE(p.created_at, i.created_at) AS top_created,
+				COALESCE(p.id, i.id)::text AS top_id, i.parent_id IS NOT NULL AS nested, i.sort_order, i.created_at
+			FROM items i `+itemTreeJoins+`
+			WHERE i.list_id = $1 AND NOT (i.checked AND $2)
+			UNION ALL
+			SELECT NULL, NULL, name, false, true, sort_order, created_at, NULL, NULL, NULL, false, NULL, NULL
+			FROM list_sections WHERE list_id = $1
+		 ) entries
+		 ORDER BY section_order ASC NULLS FIRST, section_created ASC, is_separator DESC,
+			top_order ASC, top_created DESC, top_id, nested, sort_order ASC, created_at DESC`, listID, excludeChecked)
+	if err != nil {
+		return nil, err
+	}
+	seeds := []itemSeed{}
+	index := map[string]int{}
+	for rows.Next() {
+		var seed itemSeed
+		var id, parentID *string
+		if err := rows.Scan(&id, &parentID, &seed.Name, &seed.Checked, &seed.IsSeparator); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		// A sub-item whose parent was left out (a checked parent) ends up at the top level
+		if parentID != nil {
+			if i, ok := index[*parentID]; ok {
+				seed.Parent = &i
+			}
+		}
+		if id != nil {
+			index[*id] = len(seeds)
+		}
+		seeds = append(seeds, seed)
+	}
+	return seeds, rows.Err()
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSectionInputValidate(t *testing.T) {
+	str := func(s string) *string { return &s }
+	tests := []struct {
+		name    string
+		input   sectionInput
+		wantErr string
+	}{
+		{"nothing set", sectionInput{}, ""},
+		{"all fields", sectionInput{Name: str("Dairy"), HexColor: str("42b883"), Category: str("dairy")}, ""},
+		{"colour removed", sectionInput{HexColor: str("")}, ""},
+		{"category removed", sectionInput{Category: str("")}, ""},
+		{"long name", sectionInput{Name: str(strings.Repeat("x", maxSectionNameLength+1))},
+			fmt.Sprintf("Section name must be %d characters or less", maxSectionNameLength)},
+		{"colour with #", sectionInput{HexColor: str("#42b883")}, "Invalid hex color"},
+		{"colour with markup", sectionInput{HexColor: str(`0"/><x`)}, "Invalid hex color"},
+		{"long category", sectionInput{Category: str(strings.Repeat("x", maxCategoryLength+1))},
+			fmt.Sprintf("Category must be %d characters or less", maxCategoryLength)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.input.validate()
+			got := ""
+			if err != nil {
+				got = err.Error()
+			}
+			if got != tt.wantErr {
+				t.Errorf("validate() = %q, want %q", got, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUpdateSectionWithoutFields(t *testing.T) {
+	r := httptest.NewRequest("PATCH", "/api/lists/abc/sections/1", strings.NewReader(`{}`))
+	r.SetPathValue("listId", "abc")
+	r.SetPathValue("id", "1")
+	w := httptest.NewRecorder()
+	UpdateSection(w, r)
+	if w.Code != http.StatusBadRequest || strings.TrimSpace(w.Body.String()) != "No fields to update" {
+		t.Errorf("got %d %q, want 400 \"No fields to update\"", w.Code, w.Body.String())
+	}
+}
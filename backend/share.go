@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Most items taken from one shared text
+const maxSharedItems = 100
+
+// listMarker matches bullets, numbering and checkboxes at the start of a line,
+// e.g. "- ", "* ", "• ", "1. ", "2) ", "[ ] ", "[x] ", "☐ "
+// Numbering needs a space after it, so quantities like "1.5 kg flour" are kept
+var listMarker = regexp.MustCompile(`^(?:[-*•·‣◦–—+]\s*|\d{1,3}[.)]\s+|\[[ xX]?\]\s*|[☐☑✓✔✅]\s*)+`)
+
+// parseSharedText splits text shared from another app into item names
+// Each line is an item; a single line is split at commas and semicolons instead.
+// List markers are removed; headings ("Groceries:"), links and lines too long to be an item are skipped
+func parseSharedText(text string) []string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	if len(lines) == 1 {
+		lines = strings.FieldsFunc(lines[0], func(r rune) bool { return r == ',' || r == ';' })
+	}
+
+	names := []string{}
+	for _, line := range lines {
+		name := strings.TrimSpace(listMarker.ReplaceAllString(strings.TrimSpace(line), ""))
+		if name == "" || len(name) > maxItemNameLength || strings.HasSuffix(name, ":") ||
+			strings.HasPrefix(name, "http://") || strings.HasPrefix(name, "https://") {
+			continue
+		}
+		names = append(names, name)
+		if len(names) == maxSharedItems {
+			break
+		}
+	}
+	return names
+}
+
+// AddSharedItems handles POST /api/lists/{listId}/items/shared - adds text shared from another app (Web Share Target)
+// Items already on the list are unchecked instead of added twice. Returns the added and unchecked items
+func AddSharedItems(w http.ResponseWriter, r *http.Request) {
+	listID := r.PathValue("listId")
+
+	var input struct {
+		Title string `json:"title"`
+		Text  string `json:"text"`
+		URL   string `json:"url"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	// The title is usually the note's name, so it's only used when there is no text.
+	// A shared url alone (e.g. a recipe) has no items to take
+	text := input.Text
+	if strings.TrimSpace(text) == "" {
+		text = input.Title
+	}
+	names := parseSharedText(text)
+	if len(names) == 0 {
+		http.Error(w, "No items found in shared text", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := DB.Begin(context.Background())
+	if err != nil {
+		http.Error(w, "Failed to add items", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	// Lock the list before reading its items so a concurrent share can't add the same items twice
+	if _, err := lockListForAppend(context.Background(), tx, listID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, "List not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to add items", http.StatusInternalServerError)
+		return
+	}
+
+	synonyms, err := loadSynonyms(context.Background(), tx, listID)
+	if err != nil {
+		http.Error(w, "Failed to add items", http.StatusInternalServerError)
+		return
+	}
+
+	changed := []Item{}
+	seeds := []itemSeed{}
+	seen := map[string]bool{}
+	for _, name := range names {
+		key := itemKey(name, synonyms)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		existing, err := findItemByKey(context.Background(), tx, listID, nil, key, synonyms)
+		if err != nil {
+			http.Error(w, "Failed to add items", http.StatusInternalServerError)
+			return
+		}
+		if existing == nil {
+			seeds = append(seeds, itemSeed{Name: name})
+			continue
+		}
+		if existing.Checked {
+			existing.Checked = false
+			if _, err := tx.Exec(context.Background(),
+				"UPDATE items SET checked = false WHERE id = $1", existing.ID); err != nil {
+				http.Error(w, "Failed to add items", http.StatusInternalServerError)
+				return
+			}
+			// Its sub-items are needed again too
+			if err := checkItem(context.Background(), tx, *existing); err != nil {
+				http.Error(w, "Failed to add items", http.StatusInternalServerError)
+				return
+			}
+			if err := trackItemAddition(context.Background(), tx, listID, existing.Name); err != nil {
+				http.Error(w, "Failed to add items", http.StatusInternalServerError)
+				return
+			}
+			changed = append(changed, *existing)
+		}
+	}
+
+	// appendItems records the new items in item_history too
+	added, err := appendItems(context.Background(), tx, listID, seeds)
+	if err != nil {
+		http.Error(w, "Failed to add items", http.StatusInternalServerError)
+		return
+	}
+	if err := tx.Commit(context.Background()); err != nil {
+		http.Error(w, "Failed to add items", http.StatusInternalServerError)
+		return
+	}
+	changed = append(changed, added...)
+
+	go rememberListLanguage(listID, requestLanguage(r))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(changed)
+}
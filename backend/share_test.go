@@ -0,0 +1,44 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseSharedText(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"one item per line", "Milk\nBread\nEggs", []string{"Milk", "Bread", "Eggs"}},
+		{"windows line endings", "Milk\r\nBread", []string{"Milk", "Bread"}},
+		{"single line split at commas", "Milk, Bread; Eggs", []string{"Milk", "Bread", "Eggs"}},
+		{"bullets", "- Milk\n* Bread\n• Eggs\n+ Butter", []string{"Milk", "Bread", "Eggs", "Butter"}},
+		{"numbering", "1. Milk\n2) Bread\n10. Eggs", []string{"Milk", "Bread", "Eggs"}},
+		{"checkboxes", "[ ] Milk\n[x] Bread\n[] Eggs\n☐ Butter\n✅ Cheese", []string{"Milk", "Bread", "Eggs", "Butter", "Cheese"}},
+		{"marker combinations", "- [ ] Milk\n1. - Bread", []string{"Milk", "Bread"}},
+		{"decimal quantities are kept", "1.5 kg flour\n2.5l milk", []string{"1.5 kg flour", "2.5l milk"}},
+		{"quantity after a marker", "- 1.5 kg flour\n1. 2.5l milk", []string{"1.5 kg flour", "2.5l milk"}},
+		{"headings, links and blank lines are skipped", "Groceries:\n\nMilk\nhttps://example.com/recipe\n  \nBread",
+			[]string{"Milk", "Bread"}},
+		{"only markers", "- \n[ ]", []string{}},
+		{"empty", "", []string{}},
+		{"too long for an item", strings.Repeat("a", maxItemNameLength+1) + "\nMilk", []string{"Milk"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseSharedText(tt.text); !slices.Equal(got, tt.want) {
+				t.Errorf("parseSharedText(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSharedTextLimit(t *testing.T) {
+	text := strings.Repeat("Milk\n", maxSharedItems+10)
+	if got := len(parseSharedText(text)); got != maxSharedItems {
+		t.Errorf("got %d items, want %d", got, maxSharedItems)
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Snooze limits in days
+const (
+	defaultSnoozeDays = 7
+	maxSnoozeDays     = 365
+)
+
+// HiddenRecommendation is an item that is dismissed or snoozed
+type HiddenRecommendation struct {
+	Name         string     `json:"name"`
+	Dismissed    bool       `json:"dismissed"`
+	DismissedAt  *time.Time `json:"dismissed_at"`
+	SnoozedUntil *time.Time `json:"snoozed_until"`
+}
+
+// SnoozeRecommendation handles POST /api/lists/{listId}/recommendations/{name}/snooze
+// Hides a suggestion for {"days": N} days (default 7)
+// Like a dismissal, it creates a record with no additions for items without history (popular suggestions)
+func SnoozeRecommendation(w http.ResponseWriter, r *http.Request) {
+	listID := r.PathValue("listId")
+	name := r.PathValue("name")
+	if listID == "" || name == "" {
+		http.Error(w, "List ID and item name are required", http.StatusBadRequest)
+		return
+	}
+
+	input := struct {
+		Days int `json:"days"`
+	}{Days: defaultSnoozeDays}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if input.Days < 1 || input.Days > maxSnoozeDays {
+		http.Error(w, fmt.Sprintf("days must be between 1 and %d", maxSnoozeDays), http.StatusBadRequest)
+		return
+	}
+
+	itemName, key, err := historyName(context.Background(), DB, listID, name)
+	if err != nil {
+		http.Error(w, "Failed to snooze recommendation", http.StatusInternalServerError)
+		return
+	}
+	var snoozedUntil time.Time
+	err = DB.QueryRow(context.Background(),
+		`INSERT INTO item_history (list_id, item_name, name_key, added_count, last_added_at,
+			avg_days_between, dismissed, snoozed_until)
+		VALUES ($1, $2, $3, 0, NOW(), NULL, false, NOW() + make_interval(days => $4))
+		ON CONFLICT (list_id, name_key) DO UPDATE SET snoozed_until = EXCLUDED.snoozed_until
+		RETURNING snoozed_until`,
+		listID, itemName, key, input.Days).Scan(&snoozedUntil)
+	if err != nil {
+		http.Error(w, "Failed to snooze recommendation", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"name":          name,
+		"snoozed_until": snoozedUntil,
+	})
+}
+
+// RestoreRecommendation handles POST /api/lists/{listId}/recommendations/{name}/restore
+// Undoes a dismissal or snooze
+func RestoreRecommendation(w http.ResponseWriter, r *http.Request) {
+	listID := r.PathValue("listId")
+	name := r.PathValue("name")
+	if listID == "" || name == "" {
+		http.Error(w, "List ID and item name are required", http.StatusBadRequest)
+		return
+	}
+
+	key, err := historyKey(context.Background(), listID, name)
+	if err != nil {
+		http.Error(w, "Failed to restore recommendation", http.StatusInternalServerError)
+		return
+	}
+	result, err := DB.Exec(context.Background(),
+		`UPDATE item_history SET dismissed = false, dismissed_at = NULL, snoozed_until = NULL
+		WHERE list_id = $1 AND name_key = $2`,
+		listID, key)
+	if err != nil {
+		http.Error(w, "Failed to restore recommendation", http.StatusInternalServerError)
+		return
+	}
+	if result.RowsAffected() == 0 {
+		http.Error(w, "Recommendation not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"success": true}`))
+}
+
+// GetHiddenRecommendations handles GET /api/lists/{listId}/recommendations/hidden
+// Returns dismissed and currently snoozed suggestions, most recent first
+func GetHiddenRecommendations(w http.ResponseWriter, r *http.Request) {
+	listID := r.PathValue("listId")
+	if listID == "" {
+		http.Error(w, "List ID is required", http.StatusBadRequest)
+		return
+	}
+
+	rows, err := DB.Query(context.Background(),
+		`SELECT item_name, dismissed, dismissed_at, snoozed_until
+		FROM item_history
+		WHERE list_id = $1 AND (dismissed OR snoozed_until > NOW())
+		ORDER BY GREATEST(dismissed_at, snoozed_until) DESC NULLS LAST, item_name`, listID)
+	if err != nil {
+		http.Error(w, "Failed to fetch recommendations", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	hidden := []HiddenRecommendation{}
+	for rows.Next() {
+		var h HiddenRecommendation
+		if err := rows.Scan(&h.Name, &h.Dismissed, &h.DismissedAt, &h.SnoozedUntil); err != nil {
+			http.Error(w, "Failed to scan recommendation", http.StatusInternalServerError)
+			return
+		}
+		hidden = append(hidden, h)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(hidden)
+}
+
+// historyKey resolves an item name from a URL to its item_history key
+func historyKey(ctx context.Context, listID, name string) (string, error) {
+	synonyms, err := loadSynonyms(ctx, DB, listID)
+	if err != nil {
+		return "", err
+	}
+	return itemKey(name, synonyms), nil
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Items can have sub-items (e.g. "Cake ingredients" -> flour, eggs, sugar), one level deep.
+// A sub-item is always in its parent's section. Checking or unchecking a parent does the same
+// to its sub-items, and a parent is checked exactly when all of its sub-items are
+
+var (
+	errParentNotFound = errors.New("parent item not found")
+	errNestingTooDeep = errors.New("sub-items can't have sub-items")
+)
+
+// itemTreeJoins and itemTreeOrder sort a list's items (aliased i) for display: items without a section first,
+// then section by section, each item followed by its sub-items
+const (
+	itemTreeJoins = `LEFT JOIN items p ON p.id = i.parent_id
+		LEFT JOIN list_sections s ON s.id = COALESCE(p.section_id, i.section_id)`
+	itemTreeOrder = `s.sort_order ASC NULLS FIRST, s.created_at ASC,
+		COALESCE(p.sort_order, i.sort_order) ASC, COALESCE(p.created_at, i.created_at) DESC, COALESCE(p.id, i.id),
+		i.parent_id IS NOT NULL, i.sort_order ASC, i.created_at DESC`
+)
+
+// resolveParent checks that itemID (empty for a new item) can become a sub-item of parentID
+// and returns its place: the parent and the parent's section. An empty parentID is the top level
+func resolveParent(ctx context.Context, q querier, listID, itemID, parentID string) (itemPlace, error) {
+	var place itemPlace
+	if parentID == "" {
+		return place, nil
+	}
+	if parentID == itemID {
+		return place, errNestingTooDeep
+	}
+
+	var id string
+	var nested bool
+	err := q.QueryRow(ctx,
+		`SELECT id::text, section_id::text, parent_id IS NOT NULL FROM items
+		 WHERE id::text = $1 AND list_id = $2`,
+		parentID, listID).Scan(&id, &place.SectionID, &nested)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return place, errParentNotFound
+	}
+	if err != nil {
+		return place, err
+	}
+	if nested {
+		return place, errNestingTooDeep
+	}
+
+	// An item with sub-items of its own can't be nested either
+	if itemID != "" {
+		var hasSubItems bool
+		err := q.QueryRow(ctx,
+			"SELECT EXISTS(SELECT 1 FROM items WHERE parent_id::text = $1)", itemID).Scan(&hasSubItems)
+		if err != nil {
+			return place, err
+		}
+		if hasSubItems {
+			return place, errNestingTooDeep
+		}
+	}
+	place.ParentID = &id
+	return place, nil
+}
+
+// checkItem passes a changed checked state on: a parent's sub-items get the same state,
+// and the parent of a sub-item is checked once all of its sub-items are
+func checkItem(ctx context.Context, q querier, item Item) error {
+	_, err := q.Exec(ctx,
+		"UPDATE items SET checked = $2 WHERE parent_id::text = $1 AND checked <> $2",
+		item.ID, item.Checked)
+	if err != nil {
+		return err
+	}
+	return syncParentChecked(ctx, q, item.ParentID)
+}
+
+// syncParentChecked checks a parent if all of its sub-items are checked, and unchecks it otherwise
+// A parent without sub-items (e.g. its last one was deleted) keeps its state
+func syncParentChecked(ctx context.Context, q querier, parentID *string) error {
+	if parentID == nil {
+		return nil
+	}
+	_, err := q.Exec(ctx,
+		`UPDATE items p SET checked = NOT EXISTS (SELECT 1 FROM items c WHERE c.parent_id = p.id AND NOT c.checked)
+		 WHERE p.id::text = $1 AND EXISTS (SELECT 1 FROM items c WHERE c.parent_id = p.id)`,
+		*parentID)
+	return err
+}
+
+// promoteSubItems moves an item's sub-items up to its level, in its place, before the item is deleted
+// Returns pgx.ErrNoRows if the item doesn't exist, and whether the list should be rebalanced
+func promoteSubItems(ctx context.Context, tx pgx.Tx, listID, id string) (bool, error) {
+	var parent rankedItem
+	var place itemPlace
+	err := tx.QueryRow(ctx,
+		`SELECT id::text, COALESCE(sort_order, 0), section_id::text, parent_id::text FROM items
+		 WHERE id::text = $1 AND list_id = $2`,
+		id, listID).Scan(&parent.ID, &parent.Rank, &place.SectionID, &place.ParentID)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := tx.Query(ctx,
+		`SELECT id::text FROM items WHERE parent_id::text = $1
+		 ORDER BY sort_order ASC, created_at DESC`, parent.ID)
+	if err != nil {
+		return false, err
+	}
+	subItems, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil || len(subItems) == 0 {
+		return false, err
+	}
+
+	siblings, err := loadRanks(ctx, tx, listID, place, parent.ID)
+	if err != nil {
+		return false, err
+	}
+	ranks, dense := promotedRanks(parent.Rank, siblings, len(subItems))
+	for i, subItem := range subItems {
+		_, err := tx.Exec(ctx,
+			"UPDATE items SET parent_id = NULL, sort_order = $2 WHERE id::text = $1",
+			subItem, ranks[i])
+		if err != nil {
+			return false, err
+		}
+	}
+	return dense, nil
+}
+
+// promotedRanks spreads n sub-items over the ranks from their parent's up to its next sibling's
+// (siblings in display order, without the parent), so they keep their order in its place
+// Returns the ranks and whether they are too close together
+func promotedRanks(parentRank float64, siblings []rankedItem, n int) ([]float64, bool) {
+	lo, hi := parentRank, parentRank+rankStep
+	for _, sibling := range siblings {
+		if sibling.Rank > lo {
+			hi = sibling.Rank
+			break
+		}
+	}
+	step := (hi - lo) / float64(n)
+	ranks := make([]float64, n)
+	for i := range ranks {
+		ranks[i] = lo + step*float64(i)
+	}
+	return ranks, step < rankDenseGap
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestResolveParentWithoutLookup(t *testing.T) {
+	// Neither case needs the database, so a nil querier must not be touched
+	tests := []struct {
+		name             string
+		itemID, parentID string
+		wantErr          error
+	}{
+		{"top level", "1", "", nil},
+		{"new top-level item", "", "", nil},
+		{"item as its own parent", "1", "1", errNestingTooDeep},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			place, err := resolveParent(context.Background(), nil, "list", tt.itemID, tt.parentID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if place.ParentID != nil || place.SectionID != nil {
+				t.Errorf("place = %+v, want the top level", place)
+			}
+		})
+	}
+}
+
+// execRecorder is a querier that records the arguments of each Exec, for logic that only writes
+type execRecorder struct {
+	execs [][]any
+	err   error
+}
+
+func (q *execRecorder) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	q.execs = append(q.execs, args)
+	return pgconn.CommandTag{}, q.err
+}
+
+func (q *execRecorder) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	panic("unexpected Query")
+}
+
+func (q *execRecorder) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	panic("unexpected QueryRow")
+}
+
+func TestCheckItemCascade(t *testing.T) {
+	parentID := "p"
+	tests := []struct {
+		name string
+		item Item
+		want [][]any // arguments of the sub-item update, then of the parent sync
+	}{
+		{"checking a parent checks its sub-items", Item{ID: "p", Checked: true},
+			[][]any{{"p", true}}},
+		{"unchecking a parent unchecks its sub-items", Item{ID: "p", Checked: false},
+			[][]any{{"p", false}}},
+		{"checking a sub-item syncs its parent", Item{ID: "c", Checked: true, ParentID: &parentID},
+			[][]any{{"c", true}, {"p"}}},
+		{"unchecking a sub-item syncs its parent", Item{ID: "c", Checked: false, ParentID: &parentID},
+			[][]any{{"c", false}, {"p"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := &execRecorder{}
+			if err := checkItem(context.Background(), q, tt.item); err != nil {
+				t.Fatalf("checkItem: %v", err)
+			}
+			if !slices.EqualFunc(q.execs, tt.want, slices.Equal) {
+				t.Errorf("updates = %v, want %v", q.execs, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckItemStopsOnError(t *testing.T) {
+	parentID := "p"
+	q := &execRecorder{err: errors.New("connection lost")}
+	err := checkItem(context.Background(), q, Item{ID: "c", Checked: true, ParentID: &parentID})
+	if err == nil || len(q.execs) != 1 {
+		t.Errorf("got %v after %d updates, want the error after 1", err, len(q.execs))
+	}
+}
+
+func TestSyncParentCheckedTopLevel(t *testing.T) {
+	q := &execRecorder{}
+	if err := syncParentChecked(context.Background(), q, nil); err != nil || len(q.execs) != 0 {
+		t.Errorf("got %v after %d updates, want nothing to do", err, len(q.execs))
+	}
+}
+
+func TestPromotedRanks(t *testing.T) {
+	tests := []struct {
+		name       string
+		parentRank float64
+		siblings   []rankedItem
+		n          int
+		want       []float64
+		wantDense  bool
+	}{
+		{"between the parent and its next sibling", 2, []rankedItem{{"a", 1}, {"b", 3}}, 2, []float64{2, 2.5}, false},
+		{"last item gets a full step", 4, []rankedItem{{"a", 1}, {"b", 3}}, 4, []float64{4, 4.25, 4.5, 4.75}, false},
+		{"no siblings", 1, nil, 1, []float64{1}, false},
+		{"siblings with the same rank are skipped", 2, []rankedItem{{"a", 2}, {"b", 2.5}}, 2, []float64{2, 2.25}, false},
+		{"too close together", 1, []rankedItem{{"b", 1 + 1e-6}}, 2, []float64{1, 1 + 0.5e-6}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, dense := promotedRanks(tt.parentRank, tt.siblings, tt.n)
+			if !slices.Equal(got, tt.want) || dense != tt.wantDense {
+				t.Errorf("promotedRanks = %v, %t, want %v, %t", got, dense, tt.want, tt.wantDense)
+			}
+		})
+	}
+}
+
+func TestTransferKeepsTreeOrder(t *testing.T) {
+	// Moved items must come out in the order the list shows them in: within a section,
+	// the tree order and the transfer order are the same
+	tree := strings.Fields(itemTreeOrder)
+	transfer := strings.Fields(transferOrder)
+	if !slices.Equal(tree[len(tree)-len(transfer):], transfer) {
+		t.Errorf("itemTreeOrder %q doesn't end with transferOrder %q", itemTreeOrder, transferOrder)
+	}
+	// Each parent is followed by its sub-items: rows are grouped by the top-level item before nesting is compared
+	group := slices.Index(transfer, "COALESCE(p.id,")
+	nested := slices.Index(transfer, "i.parent_id")
+	if group < 0 || nested < group {
+		t.Errorf("transferOrder %q doesn't group sub-items under their parent", transferOrder)
+	}
+}
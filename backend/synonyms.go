@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Synonym maps one item name onto another for a single list, e.g. "Milch" -> "Milk"
+type Synonym struct {
+	Alias     string    `json:"alias"`
+	Canonical string    `json:"canonical"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// loadSynonyms returns a list's synonyms as alias key -> canonical key
+func loadSynonyms(ctx context.Context, q querier, listID string) (map[string]string, error) {
+	rows, err := q.Query(ctx,
+		"SELECT alias_key, canonical_key FROM list_synonyms WHERE list_id = $1", listID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	synonyms := map[string]string{}
+	for rows.Next() {
+		var alias, canonical string
+		if err := rows.Scan(&alias, &canonical); err != nil {
+			return nil, err
+		}
+		synonyms[alias] = canonical
+	}
+	return synonyms, rows.Err()
+}
+
+// GetSynonyms handles GET /api/lists/{listId}/synonyms - returns a list's synonyms
+func GetSynonyms(w http.ResponseWriter, r *http.Request) {
+	rows, err := DB.Query(context.Background(),
+		`SELECT alias, canonical, created_at FROM list_synonyms
+		 WHERE list_id = $1 ORDER BY canonical, alias`, r.PathValue("listId"))
+	if err != nil {
+		http.Error(w, "Failed to fetch synonyms", http.StatusInternalServerError)
+		return
+	}
+	synonyms, err := pgx.CollectRows(rows, pgx.RowToStructByPos[Synonym])
+	if err != nil {
+		http.Error(w, "Failed to fetch synonyms", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(synonyms)
+}
+
+// CreateSynonym handles POST /api/lists/{listId}/synonyms - treats alias as the same item as canonical
+// Existing item history of the alias is merged into the canonical item
+func CreateSynonym(w http.ResponseWriter, r *http.Request) {
+	listID := r.PathValue("listId")
+
+	var input struct {
+		Alias     string `json:"alias"`
+		Canonical string `json:"canonical"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	input.Alias = cleanItemName(input.Alias)
+	input.Canonical = cleanItemName(input.Canonical)
+	if input.Alias == "" || input.Canonical == "" {
+		http.Error(w, "Alias and canonical name are required", http.StatusBadRequest)
+		return
+	}
+	if len(input.Alias) > maxItemNameLength || len(input.Canonical) > maxItemNameLength {
+		http.Error(w, fmt.Sprintf("Item names must be %d characters or less", maxItemNameLength), http.StatusBadRequest)
+		return
+	}
+
+	ctx := context.Background()
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		http.Error(w, "Failed to create synonym", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	var exists bool
+	err = tx.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM lists WHERE id = $1)", listID).Scan(&exists)
+	if err != nil {
+		http.Error(w, "Failed to create synonym", http.StatusInternalServerError)
+		return
+	}
+	if !exists {
+		http.Error(w, "List not found", http.StatusNotFound)
+		return
+	}
+
+	synonyms, err := loadSynonyms(ctx, tx, listID)
+	if err != nil {
+		http.Error(w, "Failed to create synonym", http.StatusInternalServerError)
+		return
+	}
+	// The canonical name may itself be an alias - always point at the end of the chain
+	aliasKey := normalizeItemName(input.Alias)
+	canonicalKey := itemKey(input.Canonical, synonyms)
+	if aliasKey == canonicalKey {
+		http.Error(w, "Alias and canonical name are the same item", http.StatusBadRequest)
+		return
+	}
+
+	// Synonyms that pointed at the alias now point at its canonical item
+	_, err = tx.Exec(ctx,
+		`UPDATE list_synonyms SET canonical_key = $3, canonical = $4
+		 WHERE list_id = $1 AND canonical_key = $2`,
+		listID, aliasKey, canonicalKey, input.Canonical)
+	if err != nil {
+		http.Error(w, "Failed to create synonym", http.StatusInternalServerError)
+		return
+	}
+
+	var synonym Synonym
+	err = tx.QueryRow(ctx,
+		`INSERT INTO list_synonyms (list_id, alias_key, canonical_key, alias, canonical)
+		 VALUES ($1, $2, $3, $4, $5)
+		 ON CONFLICT (list_id, alias_key) DO UPDATE
+		 SET canonical_key = EXCLUDED.canonical_key, alias = EXCLUDED.alias, canonical = EXCLUDED.canonical
+		 RETURNING alias, canonical, created_at`,
+		listID, aliasKey, canonicalKey, input.Alias, input.Canonical,
+	).Scan(&synonym.Alias, &synonym.Canonical, &synonym.CreatedAt)
+	if err != nil {
+		http.Error(w, "Failed to create synonym", http.StatusInternalServerError)
+		return
+	}
+
+	// Merge the history records, keeping the canonical one if there is one
+	rows, err := tx.Query(ctx,
+		`SELECT item_name FROM item_history
+		 WHERE list_id = $1 AND name_key IN ($2, $3)
+		 ORDER BY name_key = $3 DESC, added_count DESC`,
+		listID, aliasKey, canonicalKey)
+	if err != nil {
+		http.Error(w, "Failed to merge item history", http.StatusInternalServerError)
+		return
+	}
+	names, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		http.Error(w, "Failed to merge item history", http.StatusInternalServerError)
+		return
+	}
+	if len(names) > 0 {
+		if err := mergeHistoryGroup(ctx, tx, listID, canonicalKey, names); err != nil {
+			http.Error(w, "Failed to merge item history", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		http.Error(w, "Failed to create synonym", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(synonym)
+}
+
+// DeleteSynonym handles DELETE /api/lists/{listId}/synonyms/{alias} - removes a synonym
+// Item history that was already merged stays merged
+func DeleteSynonym(w http.ResponseWriter, r *http.Request) {
+	result, err := DB.Exec(context.Background(),
+		"DELETE FROM list_synonyms WHERE list_id = $1 AND alias_key = $2",
+		r.PathValue("listId"), normalizeItemName(r.PathValue("alias")))
+	if err != nil {
+		http.Error(w, "Failed to delete synonym", http.StatusInternalServerError)
+		return
+	}
+	if result.RowsAffected() == 0 {
+		http.Error(w, "Synonym not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
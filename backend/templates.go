@@ -0,0 +1,417 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Template limits
+const maxTemplateItems = 200
+
+// Template is a reusable set of items (e.g. "BBQ party") that can become a new list or be merged into one
+type Template struct {
+	ID        string         `json:"id"`
+	Name      string         `json:"name"`
+	Emoji     *string        `json:"emoji"`
+	HexColor  string         `json:"hex_color"`
+	Language  string         `json:"language"`
+	IsBuiltin bool           `json:"is_builtin"`
+	CreatedAt time.Time      `json:"created_at"`
+	Items     []TemplateItem `json:"items,omitempty"`
+}
+
+// TemplateItem is a single entry of a template; a separator starts a new section in lists made from it
+type TemplateItem struct {
+	Name        string `json:"name"`
+	IsSeparator bool   `json:"is_separator"`
+}
+
+// DuplicateList handles POST /api/lists/{id}/duplicate - clones a list with its items and sections
+// The copy stays private if the original is
+func DuplicateList(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		http.Error(w, "List ID is required", http.StatusBadRequest)
+		return
+	}
+
+	var input struct {
+		Name           *string `json:"name"`
+		ExcludeChecked bool    `json:"exclude_checked"`
+	}
+	// An empty body is fine - all options are optional
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if input.Name != nil && (*input.Name == "" || len(*input.Name) > maxListNameLength) {
+		http.Error(w, fmt.Sprintf("Name must be 1-%d characters", maxListNameLength), http.StatusBadRequest)
+		return
+	}
+
+	var source List
+	err := DB.QueryRow(context.Background(),
+		"SELECT id, name, emoji, hex_color, is_private, created_at FROM lists WHERE id = $1",
+		id).Scan(&source.ID, &source.Name, &source.Emoji, &source.HexColor, &source.IsPrivate, &source.CreatedAt)
+	if err != nil {
+		http.Error(w, "List not found", http.StatusNotFound)
+		return
+	}
+	if input.Name != nil {
+		source.Name = *input.Name
+	}
+
+	seeds, err := listSeeds(context.Background(), DB, id, input.ExcludeChecked)
+	if err != nil {
+		http.Error(w, "Failed to fetch items", http.StatusInternalServerError)
+		return
+	}
+
+	list, items, err := createListWithItems(context.Background(), source, seeds)
+	if err != nil {
+		http.Error(w, "Failed to duplicate list", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]any{
+		"list":  list,
+		"items": items,
+	})
+}
+
+// GetTemplates handles GET /api/templates - returns the built-in templates
+// Templates created by users are private and only reachable by ID
+func GetTemplates(w http.ResponseWriter, r *http.Request) {
+	rows, err := DB.Query(context.Background(),
+		`SELECT id, name, emoji, hex_color, language, is_builtin, created_at
+		 FROM list_templates
+		 WHERE is_builtin AND ($1 = '' OR language = $1)
+		 ORDER BY language ASC, name ASC`, r.URL.Query().Get("lang"))
+	if err != nil {
+		http.Error(w, "Failed to fetch templates", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	templates := []Template{}
+	for rows.Next() {
+		var t Template
+		err := rows.Scan(&t.ID, &t.Name, &t.Emoji, &t.HexColor, &t.Language, &t.IsBuiltin, &t.CreatedAt)
+		if err != nil {
+			http.Error(w, "Failed to scan template", http.StatusInternalServerError)
+			return
+		}
+		templates = append(templates, t)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(templates)
+}
+
+// GetTemplate handles GET /api/templates/{id} - returns a template with its items
+func GetTemplate(w http.ResponseWriter, r *http.Request) {
+	t, err := fetchTemplate(context.Background(), r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Template not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(t)
+}
+
+// CreateTemplate handles POST /api/templates - saves a template from explicit items or from an existing list
+func CreateTemplate(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Name           string         `json:"name"`
+		Emoji          *string        `json:"emoji"`
+		HexColor       string         `json:"hex_color"`
+		Language       string         `json:"language"`
+		Items          []TemplateItem `json:"items"`
+		FromListID     string         `json:"from_list_id"`
+		ExcludeChecked bool           `json:"exclude_checked"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if input.Name == "" {
+		http.Error(w, "Name is required", http.StatusBadRequest)
+		return
+	}
+	if len(input.Name) > maxListNameLength {
+		http.Error(w, fmt.Sprintf("Name must be %d characters or less", maxListNameLength), http.StatusBadRequest)
+		return
+	}
+	if input.HexColor == "" {
+		input.HexColor = "42b883"
+	}
+	if !validHexColor(input.HexColor) {
+		http.Error(w, "Invalid hex color", http.StatusBadRequest)
+		return
+	}
+	if input.Language == "" {
+		input.Language = "en"
+	}
+
+	// Take the items from an existing list if requested; its sections become separators
+	if input.FromListID != "" {
+		seeds, err := listSeeds(context.Background(), DB, input.FromListID, input.ExcludeChecked)
+		if err != nil {
+			http.Error(w, "Failed to fetch items", http.StatusInternalServerError)
+			return
+		}
+		input.Items = make([]TemplateItem, len(seeds))
+		for i, seed := range seeds {
+			input.Items[i] = TemplateItem{Name: seed.Name, IsSeparator: seed.IsSeparator}
+		}
+	}
+
+	if len(input.Items) > maxTemplateItems {
+		http.Error(w, fmt.Sprintf("Templates can have at most %d items", maxTemplateItems), http.StatusBadRequest)
+		return
+	}
+	for _, item := range input.Items {
+		if item.Name == "" && !item.IsSeparator {
+			http.Error(w, "Item name is required", http.StatusBadRequest)
+			return
+		}
+		if len(item.Name) > maxItemNameLength {
+			http.Error(w, fmt.Sprintf("Item name must be %d characters or less", maxItemNameLength), http.StatusBadRequest)
+			return
+		}
+	}
+
+	tx, err := DB.Begin(context.Background())
+	if err != nil {
+		http.Error(w, "Failed to create template", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	var t Template
+	err = tx.QueryRow(context.Background(),
+		`INSERT INTO list_templates (name, emoji, hex_color, language)
+		 VALUES ($1, $2, $3, $4)
+		 RETURNING id, name, emoji, hex_color, language, is_builtin, created_at`,
+		input.Name, input.Emoji, input.HexColor, input.Language,
+	).Scan(&t.ID, &t.Name, &t.Emoji, &t.HexColor, &t.Language, &t.IsBuiltin, &t.CreatedAt)
+	if err != nil {
+		http.Error(w, "Failed to create template", http.StatusInternalServerError)
+		return
+	}
+
+	for i, item := range input.Items {
+		_, err := tx.Exec(context.Background(),
+			`INSERT INTO template_items (template_id, name, is_separator, sort_order)
+			 VALUES ($1, $2, $3, $4)`,
+			t.ID, item.Name, item.IsSeparator, float64(i+1))
+		if err != nil {
+			http.Error(w, "Failed to create template", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := tx.Commit(context.Background()); err != nil {
+		http.Error(w, "Failed to create template", http.StatusInternalServerError)
+		return
+	}
+
+	t.Items = input.Items
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(t)
+}
+
+// DeleteTemplate handles DELETE /api/templates/{id} - deletes a user template
+func DeleteTemplate(w http.ResponseWriter, r *http.Request) {
+	result, err := DB.Exec(context.Background(),
+		"DELETE FROM list_templates WHERE id = $1 AND NOT is_builtin", r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Failed to delete template", http.StatusInternalServerError)
+		return
+	}
+	if result.RowsAffected() == 0 {
+		http.Error(w, "Template not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// InstantiateTemplate handles POST /api/templates/{id}/instantiate - creates a new list from a template
+func InstantiateTemplate(w http.ResponseWriter, r *http.Request) {
+	t, err := fetchTemplate(context.Background(), r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Template not found", http.StatusNotFound)
+		return
+	}
+
+	var input struct {
+		Name     *string `json:"name"`
+		Emoji    *string `json:"emoji"`
+		HexColor *string `json:"hex_color"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if input.Name != nil {
+		if *input.Name == "" || len(*input.Name) > maxListNameLength {
+			http.Error(w, fmt.Sprintf("Name must be 1-%d characters", maxListNameLength), http.StatusBadRequest)
+			return
+		}
+		t.Name = *input.Name
+	}
+	if input.Emoji != nil {
+		t.Emoji = input.Emoji
+	}
+	if input.HexColor != nil {
+		if !validHexColor(*input.HexColor) {
+			http.Error(w, "Invalid hex color", http.StatusBadRequest)
+			return
+		}
+		t.HexColor = *input.HexColor
+	}
+
+	seeds := make([]itemSeed, len(t.Items))
+	for i, item := range t.Items {
+		seeds[i] = itemSeed{Name: item.Name, IsSeparator: item.IsSeparator}
+	}
+
+	list, items, err := createListWithItems(context.Background(), List{Name: t.Name, Emoji: t.Emoji, HexColor: t.HexColor}, seeds)
+	if err != nil {
+		http.Error(w, "Failed to create list", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]any{
+		"list":  list,
+		"items": items,
+	})
+}
+
+// MergeTemplate handles POST /api/templates/{id}/merge - appends a template's items to an existing list
+// Items already on the list (same normalized name) and separators are skipped
+func MergeTemplate(w http.ResponseWriter, r *http.Request) {
+	t, err := fetchTemplate(context.Background(), r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Template not found", http.StatusNotFound)
+		return
+	}
+
+	var input struct {
+		ListID string `json:"list_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if input.ListID == "" {
+		http.Error(w, "list_id is required", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := DB.Begin(context.Background())
+	if err != nil {
+		http.Error(w, "Failed to merge template", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	// Lock the list before reading its items so a concurrent merge can't add the same items twice
+	if _, err := lockListForAppend(context.Background(), tx, input.ListID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, "List not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to merge template", http.StatusInternalServerError)
+		return
+	}
+
+	synonyms, err := loadSynonyms(context.Background(), tx, input.ListID)
+	if err != nil {
+		http.Error(w, "Failed to fetch items", http.StatusInternalServerError)
+		return
+	}
+	existing, err := listItemKeys(context.Background(), tx, input.ListID, synonyms)
+	if err != nil {
+		http.Error(w, "Failed to fetch items", http.StatusInternalServerError)
+		return
+	}
+
+	seeds := []itemSeed{}
+	for _, item := range t.Items {
+		key := itemKey(item.Name, synonyms)
+		if item.IsSeparator || existing[key] {
+			continue
+		}
+		existing[key] = true
+		seeds = append(seeds, itemSeed{Name: item.Name})
+	}
+
+	items, err := appendItems(context.Background(), tx, input.ListID, seeds)
+	if err != nil {
+		http.Error(w, "Failed to merge template", http.StatusInternalServerError)
+		return
+	}
+	if err := tx.Commit(context.Background()); err != nil {
+		http.Error(w, "Failed to merge template", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(items)
+}
+
+// fetchTemplate loads a template with its items
+func fetchTemplate(ctx context.Context, id string) (Template, error) {
+	var t Template
+	err := DB.QueryRow(ctx,
+		`SELECT id, name, emoji, hex_color, language, is_builtin, created_at
+		 FROM list_templates WHERE id = $1`,
+		id).Scan(&t.ID, &t.Name, &t.Emoji, &t.HexColor, &t.Language, &t.IsBuiltin, &t.CreatedAt)
+	if err != nil {
+		return t, err
+	}
+
+	rows, err := DB.Query(ctx,
+		`SELECT name, is_separator FROM template_items
+		 WHERE template_id = $1 ORDER BY sort_order ASC`, id)
+	if err != nil {
+		return t, err
+	}
+	t.Items, err = pgx.CollectRows(rows, pgx.RowToStructByPos[TemplateItem])
+	return t, err
+}
+
+// createListWithItems creates a list and fills it in one transaction
+func createListWithItems(ctx context.Context, input List, seeds []itemSeed) (List, []Item, error) {
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return List{}, nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	list, err := insertList(ctx, tx, input)
+	if err != nil {
+		return List{}, nil, err
+	}
+	items, err := appendItems(ctx, tx, list.ID, seeds)
+	if err != nil {
+		return List{}, nil, err
+	}
+	return list, items, tx.Commit(ctx)
+}
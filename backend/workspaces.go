@@ -0,0 +1,649 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Workspace limits
+const (
+	maxWorkspaceNameLength = 30
+	maxTokenLabelLength    = 50
+)
+
+// Workspace capabilities, from weakest to strongest
+// view: see the workspace and its lists
+// edit: add/remove lists
+// admin: rename/delete the workspace, manage tokens and members
+var capabilityRank = map[string]int{
+	"view":  1,
+	"edit":  2,
+	"admin": 3,
+}
+
+// Workspace groups several lists (e.g. a household's groceries, hardware store, pharmacy)
+type Workspace struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Emoji     *string   `json:"emoji"`
+	HexColor  string    `json:"hex_color"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ListSummary is a list with item counts, as shown on the workspace overview
+// Ref names the list within the workspace; viewers only get the ref, since a list ID grants full access
+type ListSummary struct {
+	List
+	Ref            string `json:"ref"`
+	ItemCount      int    `json:"item_count"`
+	UncheckedCount int    `json:"unchecked_count"`
+}
+
+// WorkspaceMember is a signed-in account that joined a workspace
+type WorkspaceMember struct {
+	UserID      string    `json:"user_id"`
+	DisplayName string    `json:"display_name"`
+	Role        string    `json:"role"`
+	JoinedAt    time.Time `json:"joined_at"`
+}
+
+// WorkspaceToken is the public view of a capability token (the secret is only returned on creation)
+type WorkspaceToken struct {
+	ID         string     `json:"id"`
+	Capability string     `json:"capability"`
+	Label      *string    `json:"label"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	Token      string     `json:"token,omitempty"`
+}
+
+// CreateWorkspace handles POST /api/workspaces - creates a workspace and its first admin token
+func CreateWorkspace(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Name     string  `json:"name"`
+		Emoji    *string `json:"emoji"`
+		HexColor string  `json:"hex_color"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if input.Name == "" {
+		http.Error(w, "Name is required", http.StatusBadRequest)
+		return
+	}
+	if len(input.Name) > maxWorkspaceNameLength {
+		http.Error(w, fmt.Sprintf("Name must be %d characters or less", maxWorkspaceNameLength), http.StatusBadRequest)
+		return
+	}
+	if input.HexColor == "" {
+		input.HexColor = "42b883"
+	}
+	if !validHexColor(input.HexColor) {
+		http.Error(w, "Invalid hex color", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := DB.Begin(context.Background())
+	if err != nil {
+		http.Error(w, "Failed to create workspace", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	var ws Workspace
+	err = tx.QueryRow(context.Background(),
+		`INSERT INTO workspaces (name, emoji, hex_color)
+		 VALUES ($1, $2, $3)
+		 RETURNING id, name, emoji, hex_color, created_at`,
+		input.Name, input.Emoji, input.HexColor,
+	).Scan(&ws.ID, &ws.Name, &ws.Emoji, &ws.HexColor, &ws.CreatedAt)
+	if err != nil {
+		http.Error(w, "Failed to create workspace", http.StatusInternalServerError)
+		return
+	}
+
+	token, err := createWorkspaceToken(context.Background(), tx, ws.ID, "admin", nil)
+	if err != nil {
+		http.Error(w, "Failed to create workspace", http.StatusInternalServerError)
+		return
+	}
+
+	// Signed-in creators become the owner
+	if user, err := currentUser(r); err == nil {
+		_, err = tx.Exec(context.Background(),
+			"INSERT INTO workspace_members (workspace_id, user_id, role) VALUES ($1, $2, 'owner')",
+			ws.ID, user.ID)
+		if err != nil {
+			http.Error(w, "Failed to create workspace", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := tx.Commit(context.Background()); err != nil {
+		http.Error(w, "Failed to create workspace", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]any{
+		"workspace": ws,
+		"token":     token,
+	})
+}
+
+// GetWorkspace handles GET /api/workspaces/{id} - returns the workspace with all its lists and summaries
+// List IDs are only included for edit access; viewers read lists through GetWorkspaceListItems
+func GetWorkspace(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if !requireWorkspaceCapability(w, r, id, "view") {
+		return
+	}
+	capability, _ := workspaceCapability(r, id)
+	canEdit := capabilityRank[capability] >= capabilityRank["edit"]
+
+	var ws Workspace
+	err := DB.QueryRow(context.Background(),
+		"SELECT id, name, emoji, hex_color, created_at FROM workspaces WHERE id = $1",
+		id).Scan(&ws.ID, &ws.Name, &ws.Emoji, &ws.HexColor, &ws.CreatedAt)
+	if err != nil {
+		http.Error(w, "Workspace not found", http.StatusNotFound)
+		return
+	}
+
+	rows, err := DB.Query(context.Background(),
+		`SELECT l.id, l.name, l.emoji, l.hex_color, l.is_private, l.created_at,
+			COUNT(i.id),
+			COUNT(i.id) FILTER (WHERE NOT i.checked)
+		FROM workspace_lists wl
+		JOIN lists l ON l.id = wl.list_id
+		LEFT JOIN items i ON i.list_id = l.id
+		WHERE wl.workspace_id = $1
+		GROUP BY l.id, wl.sort_order, wl.added_at
+		ORDER BY wl.sort_order ASC, wl.added_at ASC`, id)
+	if err != nil {
+		http.Error(w, "Failed to fetch lists", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	lists := []ListSummary{}
+	for rows.Next() {
+		var s ListSummary
+		err := rows.Scan(&s.ID, &s.Name, &s.Emoji, &s.HexColor, &s.IsPrivate, &s.CreatedAt,
+			&s.ItemCount, &s.UncheckedCount)
+		if err != nil {
+			http.Error(w, "Failed to scan list", http.StatusInternalServerError)
+			return
+		}
+		s.Ref = workspaceListRef(id, s.ID)
+		if !canEdit {
+			s.ID = ""
+		}
+		lists = append(lists, s)
+	}
+
+	members, err := fetchWorkspaceMembers(context.Background(), id)
+	if err != nil {
+		http.Error(w, "Failed to fetch members", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"workspace": ws,
+		"lists":     lists,
+		"members":   members,
+	})
+}
+
+// UpdateWorkspace handles PATCH /api/workspaces/{id} - renames or recolors a workspace
+func UpdateWorkspace(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if !requireWorkspaceCapability(w, r, id, "admin") {
+		return
+	}
+
+	var input struct {
+		Name     *string `json:"name"`
+		Emoji    *string `json:"emoji"`
+		HexColor *string `json:"hex_color"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if input.Name != nil && (*input.Name == "" || len(*input.Name) > maxWorkspaceNameLength) {
+		http.Error(w, fmt.Sprintf("Name must be 1-%d characters", maxWorkspaceNameLength), http.StatusBadRequest)
+		return
+	}
+	if input.HexColor != nil && !validHexColor(*input.HexColor) {
+		http.Error(w, "Invalid hex color", http.StatusBadRequest)
+		return
+	}
+
+	// COALESCE keeps the current value for fields that weren't sent
+	var ws Workspace
+	err := DB.QueryRow(context.Background(),
+		`UPDATE workspaces SET
+			name = COALESCE($1, name),
+			emoji = COALESCE($2, emoji),
+			hex_color = COALESCE($3, hex_color)
+		WHERE id = $4
+		RETURNING id, name, emoji, hex_color, created_at`,
+		input.Name, input.Emoji, input.HexColor, id,
+	).Scan(&ws.ID, &ws.Name, &ws.Emoji, &ws.HexColor, &ws.CreatedAt)
+	if err != nil {
+		http.Error(w, "Workspace not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ws)
+}
+
+// DeleteWorkspace handles DELETE /api/workspaces/{id} - deletes a workspace (its lists are kept)
+func DeleteWorkspace(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if !requireWorkspaceCapability(w, r, id, "admin") {
+		return
+	}
+
+	result, err := DB.Exec(context.Background(), "DELETE FROM workspaces WHERE id = $1", id)
+	if err != nil {
+		http.Error(w, "Failed to delete workspace", http.StatusInternalServerError)
+		return
+	}
+	if result.RowsAffected() == 0 {
+		http.Error(w, "Workspace not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AddWorkspaceList handles POST /api/workspaces/{id}/lists - adds an existing list to the workspace
+func AddWorkspaceList(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if !requireWorkspaceCapability(w, r, id, "edit") {
+		return
+	}
+
+	var input struct {
+		ListID string `json:"list_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if input.ListID == "" {
+		http.Error(w, "list_id is required", http.StatusBadRequest)
+		return
+	}
+
+	ctx := context.Background()
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		http.Error(w, "Failed to add list", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the workspace so concurrent adds don't get the same sort_order
+	_, err = tx.Exec(ctx, "SELECT 1 FROM workspaces WHERE id = $1 FOR UPDATE", id)
+	if err != nil {
+		http.Error(w, "Failed to add list", http.StatusInternalServerError)
+		return
+	}
+
+	// Append after the workspace's current last list
+	result, err := tx.Exec(ctx,
+		`INSERT INTO workspace_lists (workspace_id, list_id, sort_order)
+		 SELECT $1, l.id, COALESCE((SELECT MAX(sort_order) FROM workspace_lists WHERE workspace_id = $1), 0) + 1
+		 FROM lists l WHERE l.id = $2
+		 ON CONFLICT DO NOTHING`,
+		id, input.ListID)
+	if err != nil {
+		http.Error(w, "Failed to add list", http.StatusInternalServerError)
+		return
+	}
+	if result.RowsAffected() == 0 {
+		http.Error(w, "List not found or already in workspace", http.StatusConflict)
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		http.Error(w, "Failed to add list", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write([]byte(`{"success": true}`))
+}
+
+// RemoveWorkspaceList handles DELETE /api/workspaces/{id}/lists/{listId} - removes a list from the workspace
+// The list itself is not deleted
+func RemoveWorkspaceList(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if !requireWorkspaceCapability(w, r, id, "edit") {
+		return
+	}
+
+	result, err := DB.Exec(context.Background(),
+		"DELETE FROM workspace_lists WHERE workspace_id = $1 AND list_id = $2",
+		id, r.PathValue("listId"))
+	if err != nil {
+		http.Error(w, "Failed to remove list", http.StatusInternalServerError)
+		return
+	}
+	if result.RowsAffected() == 0 {
+		http.Error(w, "List not found in workspace", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetWorkspaceListItems handles GET /api/workspaces/{id}/lists/{ref}/items - returns a list's items read-only
+// The list is named by its ref, and the items come without the list ID
+func GetWorkspaceListItems(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if !requireWorkspaceCapability(w, r, id, "view") {
+		return
+	}
+
+	rows, err := DB.Query(context.Background(),
+		"SELECT list_id FROM workspace_lists WHERE workspace_id = $1", id)
+	if err != nil {
+		http.Error(w, "Failed to fetch items", http.StatusInternalServerError)
+		return
+	}
+	listIDs, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		http.Error(w, "Failed to fetch items", http.StatusInternalServerError)
+		return
+	}
+	listID := ""
+	for _, candidate := range listIDs {
+		if workspaceListRef(id, candidate) == r.PathValue("ref") {
+			listID = candidate
+		}
+	}
+	if listID == "" {
+		http.Error(w, "List not found in workspace", http.StatusNotFound)
+		return
+	}
+
+	items, err := fetchItems(context.Background(), listID)
+	if err != nil {
+		http.Error(w, "Failed to fetch items", http.StatusInternalServerError)
+		return
+	}
+	for i := range items {
+		items[i].ListID = ""
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(items)
+}
+
+// workspaceListRef derives a stable name for a list within a workspace that doesn't reveal the list ID
+func workspaceListRef(workspaceID, listID string) string {
+	return hex.EncodeToString(hashToken(workspaceID + "/" + listID)[:8])
+}
+
+// GetWorkspaceTokens handles GET /api/workspaces/{id}/tokens - lists capability tokens (without secrets)
+func GetWorkspaceTokens(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if !requireWorkspaceCapability(w, r, id, "admin") {
+		return
+	}
+
+	rows, err := DB.Query(context.Background(),
+		`SELECT id, capability, label, created_at, revoked_at
+		 FROM workspace_tokens WHERE workspace_id = $1
+		 ORDER BY created_at ASC`, id)
+	if err != nil {
+		http.Error(w, "Failed to fetch tokens", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	tokens := []WorkspaceToken{}
+	for rows.Next() {
+		var t WorkspaceToken
+		if err := rows.Scan(&t.ID, &t.Capability, &t.Label, &t.CreatedAt, &t.RevokedAt); err != nil {
+			http.Error(w, "Failed to scan token", http.StatusInternalServerError)
+			return
+		}
+		tokens = append(tokens, t)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tokens)
+}
+
+// CreateWorkspaceTokenHandler handles POST /api/workspaces/{id}/tokens - creates a new share token
+// The secret is only returned in this response
+func CreateWorkspaceTokenHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if !requireWorkspaceCapability(w, r, id, "admin") {
+		return
+	}
+
+	var input struct {
+		Capability string  `json:"capability"`
+		Label      *string `json:"label"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if _, ok := capabilityRank[input.Capability]; !ok {
+		http.Error(w, "capability must be view, edit or admin", http.StatusBadRequest)
+		return
+	}
+	if input.Label != nil && len(*input.Label) > maxTokenLabelLength {
+		http.Error(w, fmt.Sprintf("Label must be %d characters or less", maxTokenLabelLength), http.StatusBadRequest)
+		return
+	}
+
+	token, err := createWorkspaceToken(context.Background(), DB, id, input.Capability, input.Label)
+	if err != nil {
+		http.Error(w, "Failed to create token", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(token)
+}
+
+// RevokeWorkspaceToken handles DELETE /api/workspaces/{id}/tokens/{tokenId} - revokes a share token
+func RevokeWorkspaceToken(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if !requireWorkspaceCapability(w, r, id, "admin") {
+		return
+	}
+
+	result, err := DB.Exec(context.Background(),
+		`UPDATE workspace_tokens SET revoked_at = NOW()
+		 WHERE workspace_id = $1 AND id::text = $2 AND revoked_at IS NULL`,
+		id, r.PathValue("tokenId"))
+	if err != nil {
+		http.Error(w, "Failed to revoke token", http.StatusInternalServerError)
+		return
+	}
+	if result.RowsAffected() == 0 {
+		http.Error(w, "Token not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// JoinWorkspace handles POST /api/workspaces/{id}/members - the signed-in user joins using an edit/admin token
+// Members keep access without the token, even if it's revoked later
+func JoinWorkspace(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	user, err := currentUser(r)
+	if err != nil {
+		http.Error(w, "Not signed in", http.StatusUnauthorized)
+		return
+	}
+	if !requireWorkspaceCapability(w, r, id, "edit") {
+		return
+	}
+
+	_, err = DB.Exec(context.Background(),
+		`INSERT INTO workspace_members (workspace_id, user_id) VALUES ($1, $2)
+		 ON CONFLICT DO NOTHING`,
+		id, user.ID)
+	if err != nil {
+		http.Error(w, "Failed to join workspace", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write([]byte(`{"success": true}`))
+}
+
+// RemoveWorkspaceMember handles DELETE /api/workspaces/{id}/members/{userId}
+// Members can leave on their own, admins can remove anyone
+func RemoveWorkspaceMember(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	userID := r.PathValue("userId")
+
+	if user, err := currentUser(r); err != nil || user.ID != userID {
+		if !requireWorkspaceCapability(w, r, id, "admin") {
+			return
+		}
+	}
+
+	result, err := DB.Exec(context.Background(),
+		"DELETE FROM workspace_members WHERE workspace_id = $1 AND user_id = $2",
+		id, userID)
+	if err != nil {
+		http.Error(w, "Failed to remove member", http.StatusInternalServerError)
+		return
+	}
+	if result.RowsAffected() == 0 {
+		http.Error(w, "Member not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// errNoWorkspaceAccess is returned when neither a token nor a membership grants access
+var errNoWorkspaceAccess = errors.New("no workspace access")
+
+// workspaceCapability returns the strongest capability the request holds for a workspace
+// Access comes from a capability token ("X-Workspace-Token" header or ?token=) or from membership
+func workspaceCapability(r *http.Request, workspaceID string) (string, error) {
+	best := ""
+	upgrade := func(c string) {
+		if capabilityRank[c] > capabilityRank[best] {
+			best = c
+		}
+	}
+
+	token := r.Header.Get("X-Workspace-Token")
+	if token == "" {
+		token = r.URL.Query().Get("token")
+	}
+	if token != "" {
+		var capability string
+		err := DB.QueryRow(context.Background(),
+			`SELECT capability FROM workspace_tokens
+			 WHERE workspace_id = $1 AND token_hash = $2 AND revoked_at IS NULL`,
+			workspaceID, hashToken(token)).Scan(&capability)
+		if err == nil {
+			upgrade(capability)
+		}
+	}
+
+	if user, err := currentUser(r); err == nil {
+		var role string
+		err := DB.QueryRow(context.Background(),
+			"SELECT role FROM workspace_members WHERE workspace_id = $1 AND user_id = $2",
+			workspaceID, user.ID).Scan(&role)
+		if err == nil {
+			if role == "owner" {
+				upgrade("admin")
+			} else {
+				upgrade("edit")
+			}
+		}
+	}
+
+	if best == "" {
+		return "", errNoWorkspaceAccess
+	}
+	return best, nil
+}
+
+// requireWorkspaceCapability writes an error response and returns false if the request lacks the capability
+// Unknown workspaces and missing access both return 404 so workspace IDs can't be probed
+func requireWorkspaceCapability(w http.ResponseWriter, r *http.Request, workspaceID, needed string) bool {
+	if workspaceID == "" {
+		http.Error(w, "Workspace ID is required", http.StatusBadRequest)
+		return false
+	}
+
+	capability, err := workspaceCapability(r, workspaceID)
+	if err != nil {
+		http.Error(w, "Workspace not found", http.StatusNotFound)
+		return false
+	}
+	if capabilityRank[capability] < capabilityRank[needed] {
+		http.Error(w, fmt.Sprintf("This action requires %s access", needed), http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// createWorkspaceToken stores a new capability token and returns it including the secret
+func createWorkspaceToken(ctx context.Context, q querier, workspaceID, capability string, label *string) (WorkspaceToken, error) {
+	t := WorkspaceToken{Capability: capability, Label: label, Token: randomToken(24)}
+	err := q.QueryRow(ctx,
+		`INSERT INTO workspace_tokens (workspace_id, token_hash, capability, label)
+		 VALUES ($1, $2, $3, $4)
+		 RETURNING id::text, created_at`,
+		workspaceID, hashToken(t.Token), capability, label,
+	).Scan(&t.ID, &t.CreatedAt)
+	return t, err
+}
+
+// fetchWorkspaceMembers returns the workspace's members, owners first
+func fetchWorkspaceMembers(ctx context.Context, workspaceID string) ([]WorkspaceMember, error) {
+	rows, err := DB.Query(ctx,
+		`SELECT m.user_id, u.display_name, m.role, m.joined_at
+		 FROM workspace_members m JOIN users u ON u.id = m.user_id
+		 WHERE m.workspace_id = $1
+		 ORDER BY m.role = 'owner' DESC, m.joined_at ASC`, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []WorkspaceMember{}
+	for rows.Next() {
+		var m WorkspaceMember
+		if err := rows.Scan(&m.UserID, &m.DisplayName, &m.Role, &m.JoinedAt); err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+	return members, rows.Err()
+}
@@ -28,6 +28,16 @@ const messages = {
 
     // List page
     share_btn: 'Share',
+    qr_code: 'QR code',
+    shopping_trip: 'Shopping trip',
+    done_shopping: 'Done',
+    clear_checked: 'Clear checked',
+    uncheck_all: 'Uncheck all',
+    add_section: 'Add section',
+    section_name: 'Section name',
+    delete_section_confirm: 'Delete this section?',
+    add_sub_item: 'Add sub-item',
+    sub_item_name: 'Sub-item name',
     new_list: 'New List',
     add_item: 'Add an item...',
     add_btn: 'Add',
@@ -72,6 +82,16 @@ const messages = {
 
     // List page
     share_btn: 'Teilen',
+    qr_code: 'QR-Code',
+    shopping_trip: 'Einkauf',
+    done_shopping: 'Fertig',
+    clear_checked: 'Erledigte entfernen',
+    uncheck_all: 'Alle zurucksetzen',
+    add_section: 'Abschnitt hinzufugen',
+    section_name: 'Name des Abschnitts',
+    delete_section_confirm: 'Diesen Abschnitt loschen?',
+    add_sub_item: 'Unterpunkt hinzufugen',
+    sub_item_name: 'Name des Unterpunkts',
     new_list: 'Neue Liste',
     add_item: 'Eintrag hinzufugen...',
     add_btn: 'Hinzufugen',